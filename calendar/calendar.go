@@ -0,0 +1,171 @@
+package calendar
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+const dateFormat = "2006-01-02"
+
+// DefaultWorkWeek is Monday to Friday.
+var DefaultWorkWeek = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+// Calendar knows which days are worked, so ages can be counted in business days and
+// a long weekend doesn't make everything look overdue.
+type Calendar struct {
+	location *time.Location
+	workdays map[time.Weekday]bool
+	holidays map[string]bool
+}
+
+// New returns a calendar where the days of workWeek are worked, except for holidays,
+// with days starting at midnight in location. An empty work week is DefaultWorkWeek.
+func New(location *time.Location, workWeek []time.Weekday, holidays []time.Time) *Calendar {
+	if location == nil {
+		location = time.Local
+	}
+	if len(workWeek) == 0 {
+		workWeek = DefaultWorkWeek
+	}
+
+	c := Calendar{location: location, workdays: make(map[time.Weekday]bool), holidays: make(map[string]bool)}
+	for _, day := range workWeek {
+		c.workdays[day] = true
+	}
+	for _, holiday := range holidays {
+		// Holidays are dates, whatever time zone they were read in.
+		c.holidays[holiday.Format(dateFormat)] = true
+	}
+	return &c
+}
+
+// IsBusinessDay is true when the day t falls on is worked.
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	t = t.In(c.location)
+	return c.workdays[t.Weekday()] && !c.holidays[t.Format(dateFormat)]
+}
+
+// BusinessDays counts the time between start and end that falls on business days, in
+// days. Friday evening to Monday morning is a fraction of a day.
+func (c *Calendar) BusinessDays(start time.Time, end time.Time) float64 {
+	if !end.After(start) {
+		return 0
+	}
+
+	var worked time.Duration
+	day := startOfDay(start.In(c.location))
+	for day.Before(end) {
+		next := day.AddDate(0, 0, 1)
+		if c.IsBusinessDay(day) {
+			from, to := day, next
+			if start.After(from) {
+				from = start
+			}
+			if end.Before(to) {
+				to = end
+			}
+			worked += to.Sub(from)
+		}
+		day = next
+	}
+	return worked.Hours() / 24
+}
+
+// SubtractBusinessDays goes back days business days from t, skipping days off.
+func (c *Calendar) SubtractBusinessDays(t time.Time, days int) time.Time {
+	t = t.In(c.location)
+	for days > 0 {
+		t = t.AddDate(0, 0, -1)
+		if c.IsBusinessDay(t) {
+			days--
+		}
+	}
+	return t
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// ParseWeekdays reads day names such as "monday" or "Fri".
+func ParseWeekdays(names []string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, name := range names {
+		found := false
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if strings.EqualFold(name, day.String()) || strings.EqualFold(name, day.String()[:3]) {
+				days = append(days, day)
+				found = true
+			}
+		}
+		if !found {
+			return nil, errors.New("Unknown day of the week " + name)
+		}
+	}
+	return days, nil
+}
+
+// ParseDates reads dates written as 2006-01-02.
+func ParseDates(dates []string) ([]time.Time, error) {
+	var parsed []time.Time
+	for _, date := range dates {
+		t, err := time.Parse(dateFormat, strings.TrimSpace(date))
+		if err != nil {
+			return nil, errors.New("Invalid date " + date + ", use 2006-01-02")
+		}
+		parsed = append(parsed, t)
+	}
+	return parsed, nil
+}
+
+// ParseICS reads the days of the events in an iCalendar file, as exported by most
+// calendar apps for public holidays. An event lasting several days adds each of them.
+func ParseICS(r io.Reader) ([]time.Time, error) {
+	var days []time.Time
+	var start, end time.Time
+	inEvent := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "BEGIN:VEVENT":
+			inEvent = true
+			start, end = time.Time{}, time.Time{}
+		case line == "END:VEVENT":
+			inEvent = false
+			if start.IsZero() {
+				continue
+			}
+			// The end of an all-day event is the day after it.
+			if !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+			for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+				days = append(days, day)
+			}
+		case inEvent && strings.HasPrefix(line, "DTSTART"):
+			start = parseICSDate(line)
+		case inEvent && strings.HasPrefix(line, "DTEND"):
+			end = parseICSDate(line)
+		}
+	}
+	return days, scanner.Err()
+}
+
+// parseICSDate reads the date of a line like "DTSTART;VALUE=DATE:20161225" or
+// "DTSTART:20161225T090000Z", ignoring the time of day.
+func parseICSDate(line string) time.Time {
+	value := line[strings.LastIndex(line, ":")+1:]
+	if len(value) < 8 {
+		return time.Time{}
+	}
+	t, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
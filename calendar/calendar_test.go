@@ -0,0 +1,81 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func Test(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	// Friday 23 December 2016, with Monday the 26th off.
+	friday := time.Date(2016, time.December, 23, 12, 0, 0, 0, time.UTC)
+	boxingDay := time.Date(2016, time.December, 26, 0, 0, 0, 0, time.UTC)
+	c := New(time.UTC, nil, []time.Time{boxingDay})
+
+	g.Describe("Calendar", func() {
+		g.It("Should skip weekends and holidays", func() {
+			Expect(c.IsBusinessDay(friday)).To(BeTrue())
+			Expect(c.IsBusinessDay(friday.AddDate(0, 0, 1))).To(BeFalse())
+			Expect(c.IsBusinessDay(boxingDay)).To(BeFalse())
+			Expect(c.IsBusinessDay(boxingDay.AddDate(0, 0, 1))).To(BeTrue())
+		})
+
+		g.It("Should count only the time on business days", func() {
+			tuesdayNoon := friday.AddDate(0, 0, 4)
+
+			Expect(c.BusinessDays(friday, tuesdayNoon)).To(BeNumerically("~", 1, 0.001))
+			Expect(c.BusinessDays(tuesdayNoon, friday)).To(Equal(0.0))
+		})
+
+		g.It("Should go back over days off", func() {
+			tuesday := friday.AddDate(0, 0, 4)
+
+			Expect(c.SubtractBusinessDays(tuesday, 1)).To(Equal(friday))
+		})
+
+		g.It("Should use a custom work week", func() {
+			days, err := ParseWeekdays([]string{"sunday", "Mon", "tuesday", "wednesday", "thu"})
+			Expect(err).To(BeNil())
+
+			sundayToThursday := New(time.UTC, days, nil)
+			Expect(sundayToThursday.IsBusinessDay(friday)).To(BeFalse())
+			Expect(sundayToThursday.IsBusinessDay(friday.AddDate(0, 0, 2))).To(BeTrue())
+
+			_, err = ParseWeekdays([]string{"someday"})
+			Expect(err).ToNot(BeNil())
+		})
+
+		g.It("Should read holidays from an iCalendar file", func() {
+			ics := strings.Join([]string{
+				"BEGIN:VCALENDAR",
+				"BEGIN:VEVENT",
+				"DTSTART;VALUE=DATE:20161225",
+				"DTEND;VALUE=DATE:20161227",
+				"SUMMARY:Christmas",
+				"END:VEVENT",
+				"BEGIN:VEVENT",
+				"DTSTART:20170101T000000Z",
+				"SUMMARY:New Year",
+				"END:VEVENT",
+				"END:VCALENDAR",
+			}, "\r\n")
+
+			days, err := ParseICS(strings.NewReader(ics))
+
+			Expect(err).To(BeNil())
+			Expect(days).To(Equal([]time.Time{
+				time.Date(2016, time.December, 25, 0, 0, 0, 0, time.UTC),
+				time.Date(2016, time.December, 26, 0, 0, 0, 0, time.UTC),
+				time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC),
+			}))
+		})
+	})
+}
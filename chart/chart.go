@@ -0,0 +1,376 @@
+// Package chart draws the few charts Marvin posts to Slack as PNG images, using
+// nothing but the standard library so it runs anywhere Marvin does.
+package chart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+)
+
+// Kind is how the series of a chart are drawn.
+type Kind int
+
+const (
+	// StackedArea piles each series on top of the ones before it.
+	StackedArea Kind = iota
+	// Lines draws each series as its own line.
+	Lines
+	// Bars draws the first series as one bar per label.
+	Bars
+)
+
+// Series is one set of values, one for each label of the chart.
+type Series struct {
+	Name   string
+	Values []float64
+	// Color is optional, series without one take the next colour of the Palette.
+	Color color.Color
+}
+
+// Chart is a chart with labels along the x axis and values up the y axis.
+type Chart struct {
+	Title  string
+	Kind   Kind
+	Labels []string
+	Series []Series
+	Width  int
+	Height int
+}
+
+var (
+	Palette = []color.RGBA{
+		{0x43, 0x9F, 0xE0, 0xFF},
+		{0x36, 0xA6, 0x4F, 0xFF},
+		{0xFF, 0xD3, 0x34, 0xFF},
+		{0xFF, 0x72, 0x22, 0xFF},
+		{0xFF, 0x10, 0x10, 0xFF},
+		{0x8E, 0x44, 0xAD, 0xFF},
+		{0x1A, 0xBC, 0x9C, 0xFF},
+		{0xA0, 0xA0, 0xA0, 0xFF},
+	}
+
+	background = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	ink        = color.RGBA{0x33, 0x33, 0x33, 0xFF}
+	gridLine   = color.RGBA{0xE6, 0xE6, 0xE6, 0xFF}
+)
+
+const (
+	defaultWidth  = 800
+	defaultHeight = 400
+
+	marginTop    = 36
+	marginBottom = 30
+	marginLeft   = 48
+	marginRight  = 16
+	legendWidth  = 150
+	gridLines    = 5
+)
+
+// CumulativeFlow stacks the number of issues in each lane over time. List the lanes
+// from the end of the board to the start so the finished work sits at the bottom.
+func CumulativeFlow(title string, dates []string, lanes []Series) Chart {
+	return Chart{Title: title, Kind: StackedArea, Labels: dates, Series: lanes}
+}
+
+// Burndown draws the work remaining each day next to the ideal line towards zero.
+func Burndown(title string, dates []string, remaining []float64, ideal []float64) Chart {
+	return Chart{
+		Title:  title,
+		Kind:   Lines,
+		Labels: dates,
+		Series: []Series{
+			{Name: "Remaining", Values: remaining, Color: Palette[0]},
+			{Name: "Ideal", Values: ideal, Color: Palette[7]},
+		},
+	}
+}
+
+// Histogram draws how many things fall in each bucket, such as pull requests by age.
+func Histogram(title string, buckets []string, counts []float64) Chart {
+	return Chart{
+		Title:  title,
+		Kind:   Bars,
+		Labels: buckets,
+		Series: []Series{{Name: title, Values: counts, Color: Palette[4]}},
+	}
+}
+
+// PNG renders the chart and encodes it as a PNG image.
+func (c Chart) PNG() ([]byte, error) {
+	var buffer bytes.Buffer
+	err := png.Encode(&buffer, c.Render())
+	return buffer.Bytes(), err
+}
+
+// Render draws the chart.
+func (c Chart) Render() *image.RGBA {
+	width, height := c.Width, c.Height
+	if width == 0 {
+		width = defaultWidth
+	}
+	if height == 0 {
+		height = defaultHeight
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{background}, image.ZP, draw.Src)
+
+	drawText(img, (width-textWidth(c.Title, 2))/2, 10, fitText(c.Title, width-20, 2), ink, 2)
+
+	right := width - marginRight
+	if c.hasLegend() {
+		right -= legendWidth
+		c.drawLegend(img, right+12, marginTop)
+	}
+	plot := image.Rect(marginLeft, marginTop, right, height-marginBottom)
+
+	top := niceCeiling(c.maxValue())
+	c.drawGrid(img, plot, top)
+
+	switch c.Kind {
+	case StackedArea:
+		c.drawStackedArea(img, plot, top)
+	case Lines:
+		c.drawLines(img, plot, top)
+	case Bars:
+		c.drawBars(img, plot, top)
+	}
+
+	drawLine(img, plot.Min.X, plot.Max.Y, plot.Max.X, plot.Max.Y, ink, 1)
+	drawLine(img, plot.Min.X, plot.Min.Y, plot.Min.X, plot.Max.Y, ink, 1)
+	return img
+}
+
+func (c Chart) hasLegend() bool {
+	return c.Kind != Bars && len(c.Series) > 0
+}
+
+func (c Chart) color(i int) color.Color {
+	if c.Series[i].Color != nil {
+		return c.Series[i].Color
+	}
+	return Palette[i%len(Palette)]
+}
+
+func (c Chart) value(series int, label int) float64 {
+	values := c.Series[series].Values
+	if label < len(values) {
+		return values[label]
+	}
+	return 0
+}
+
+func (c Chart) maxValue() float64 {
+	max := 0.0
+	for label := range c.Labels {
+		total := 0.0
+		for series := range c.Series {
+			if c.Kind == StackedArea {
+				total += c.value(series, label)
+			} else {
+				total = math.Max(total, c.value(series, label))
+			}
+			if c.Kind == Bars {
+				break
+			}
+		}
+		max = math.Max(max, total)
+	}
+	return max
+}
+
+// niceCeiling rounds max up to 1, 2 or 5 times a power of ten so the grid lines
+// fall on round numbers.
+func niceCeiling(max float64) float64 {
+	if max <= 0 {
+		return gridLines
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(max/gridLines)))
+	for _, step := range []float64{1, 2, 5, 10} {
+		if step*magnitude*gridLines >= max {
+			return math.Max(step*magnitude*gridLines, gridLines)
+		}
+	}
+	return max
+}
+
+// y is the row of the plot a value is drawn at.
+func y(plot image.Rectangle, top float64, value float64) int {
+	return plot.Max.Y - int(value/top*float64(plot.Dy())+0.5)
+}
+
+// x is the column of the plot the label at index is drawn at.
+func (c Chart) x(plot image.Rectangle, index int) int {
+	if len(c.Labels) <= 1 {
+		return plot.Min.X + plot.Dx()/2
+	}
+	return plot.Min.X + index*plot.Dx()/(len(c.Labels)-1)
+}
+
+func (c Chart) drawGrid(img *image.RGBA, plot image.Rectangle, top float64) {
+	for i := 0; i <= gridLines; i++ {
+		value := top * float64(i) / gridLines
+		row := y(plot, top, value)
+		if i > 0 {
+			drawLine(img, plot.Min.X+1, row, plot.Max.X, row, gridLine, 1)
+		}
+		label := strconv.FormatFloat(value, 'f', -1, 64)
+		drawText(img, plot.Min.X-6-textWidth(label, 1), row-glyphHeight/2, label, ink, 1)
+	}
+
+	// Leave out labels that would run into each other.
+	slot := plot.Dx()
+	if len(c.Labels) > 1 {
+		slot = plot.Dx() / (len(c.Labels) - 1)
+	}
+	if c.Kind == Bars && len(c.Labels) > 0 {
+		slot = plot.Dx() / len(c.Labels)
+	}
+	widest := 0
+	for _, label := range c.Labels {
+		if w := textWidth(label, 1); w > widest {
+			widest = w
+		}
+	}
+	every := 1
+	for slot > 0 && every*slot < widest+8 {
+		every++
+	}
+
+	for i, label := range c.Labels {
+		if i%every != 0 {
+			continue
+		}
+		center := c.x(plot, i)
+		if c.Kind == Bars {
+			center = plot.Min.X + slot*i + slot/2
+		}
+		drawText(img, center-textWidth(label, 1)/2, plot.Max.Y+8, label, ink, 1)
+	}
+}
+
+func (c Chart) drawLegend(img *image.RGBA, left int, top int) {
+	for i := len(c.Series) - 1; i >= 0; i-- {
+		// The legend lists stacked series top down, as they appear in the chart.
+		row := len(c.Series) - 1 - i
+		if c.Kind != StackedArea {
+			row = i
+		}
+		rowTop := top + row*16
+		fillRect(img, left, rowTop, 10, 10, c.color(i))
+		drawText(img, left+16, rowTop+2, fitText(c.Series[i].Name, legendWidth-28, 1), ink, 1)
+	}
+}
+
+func (c Chart) drawStackedArea(img *image.RGBA, plot image.Rectangle, top float64) {
+	if len(c.Labels) == 0 {
+		return
+	}
+	lower := make([]float64, len(c.Labels))
+	for series := range c.Series {
+		upper := make([]float64, len(c.Labels))
+		for label := range c.Labels {
+			upper[label] = lower[label] + c.value(series, label)
+		}
+
+		for column := plot.Min.X; column <= plot.Max.X; column++ {
+			low := interpolate(c, plot, lower, column)
+			high := interpolate(c, plot, upper, column)
+			drawLine(img, column, y(plot, top, high), column, y(plot, top, low), c.color(series), 1)
+		}
+		lower = upper
+	}
+}
+
+// interpolate finds the value between the two labels either side of column.
+func interpolate(c Chart, plot image.Rectangle, values []float64, column int) float64 {
+	if len(values) == 1 {
+		return values[0]
+	}
+	for i := 0; i < len(values)-1; i++ {
+		x0, x1 := c.x(plot, i), c.x(plot, i+1)
+		if column >= x0 && column <= x1 {
+			if x1 == x0 {
+				return values[i]
+			}
+			fraction := float64(column-x0) / float64(x1-x0)
+			return values[i] + (values[i+1]-values[i])*fraction
+		}
+	}
+	return values[len(values)-1]
+}
+
+func (c Chart) drawLines(img *image.RGBA, plot image.Rectangle, top float64) {
+	for series := range c.Series {
+		for label := 1; label < len(c.Labels) && label < len(c.Series[series].Values); label++ {
+			drawLine(img,
+				c.x(plot, label-1), y(plot, top, c.value(series, label-1)),
+				c.x(plot, label), y(plot, top, c.value(series, label)),
+				c.color(series), 2)
+		}
+	}
+}
+
+func (c Chart) drawBars(img *image.RGBA, plot image.Rectangle, top float64) {
+	if len(c.Labels) == 0 || len(c.Series) == 0 {
+		return
+	}
+	slot := plot.Dx() / len(c.Labels)
+	gap := slot / 5
+	for label := range c.Labels {
+		value := c.value(0, label)
+		barTop := y(plot, top, value)
+		left := plot.Min.X + slot*label + gap/2
+		fillRect(img, left, barTop, slot-gap, plot.Max.Y-barTop, c.color(0))
+
+		if value > 0 {
+			text := strconv.FormatFloat(value, 'f', -1, 64)
+			drawText(img, left+(slot-gap-textWidth(text, 1))/2, barTop-glyphHeight-4, text, ink, 1)
+		}
+	}
+}
+
+func fillRect(img *image.RGBA, x int, y int, width int, height int, c color.Color) {
+	draw.Draw(img, image.Rect(x, y, x+width, y+height), &image.Uniform{c}, image.ZP, draw.Src)
+}
+
+// drawLine draws a line of the given thickness with Bresenham's algorithm.
+func drawLine(img *image.RGBA, x0 int, y0 int, x1 int, y1 int, c color.Color, thickness int) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		fillRect(img, x0-thickness/2, y0-thickness/2, thickness, thickness, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
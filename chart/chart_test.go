@@ -0,0 +1,62 @@
+package chart
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	. "github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func Test(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Chart", func() {
+		g.It("Should encode a PNG of the requested size", func() {
+			c := Histogram("Pull requests by age", []string{"0-1d", "2-7d", "8-30d", "30d+"}, []float64{3, 5, 2, 1})
+			c.Width = 400
+			c.Height = 300
+
+			encoded, err := c.PNG()
+			Expect(err).To(BeNil())
+
+			img, err := png.Decode(bytes.NewReader(encoded))
+			Expect(err).To(BeNil())
+			Expect(img.Bounds().Dx()).To(Equal(400))
+			Expect(img.Bounds().Dy()).To(Equal(300))
+		})
+
+		g.It("Should stack the series of a cumulative flow", func() {
+			c := CumulativeFlow("Flow", []string{"Mar 1", "Mar 8"}, []Series{
+				{Name: "Done", Values: []float64{2, 4}},
+				{Name: "Backlog", Values: []float64{8, 8}},
+			})
+			img := c.Render()
+
+			// The plot is 0 to 20 high, so the bottom fifth is done and the rest backlog.
+			plotBottom := defaultHeight - marginBottom
+			plotHeight := plotBottom - marginTop
+			column := marginLeft + 2
+			Expect(img.At(column, plotBottom-plotHeight/20)).To(Equal(Palette[0]))
+			Expect(img.At(column, plotBottom-plotHeight/5)).To(Equal(Palette[1]))
+			Expect(img.At(column, marginTop+plotHeight/5)).To(Equal(background))
+		})
+
+		g.It("Should round the top of the scale to a round number", func() {
+			Expect(niceCeiling(0)).To(Equal(5.0))
+			Expect(niceCeiling(7)).To(Equal(10.0))
+			Expect(niceCeiling(23)).To(Equal(25.0))
+			Expect(niceCeiling(180)).To(Equal(250.0))
+		})
+
+		g.It("Should shorten text that doesn't fit", func() {
+			text := fitText("Ready for Review", textWidth("Ready f..", 1), 1)
+
+			Expect(text).To(Equal("Ready f.."))
+		})
+	})
+}
@@ -0,0 +1,236 @@
+package githubservice
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const defaultAppBaseURL = "https://api.github.com/"
+
+// Installation tokens are refreshed this long before GitHub says they expire so
+// that a request never goes out with a token that dies in flight.
+const tokenRefreshMargin = 5 * time.Minute
+
+// App authenticates as a GitHub App. It signs JWTs with the app's private key,
+// exchanges them for installation access tokens and caches those tokens per
+// installation until they are close to expiring.
+type App struct {
+	ID            int
+	BaseURL       string
+	Installations map[string]int
+
+	privateKey *rsa.PrivateKey
+	client     *http.Client
+	mutex      sync.Mutex
+	tokens     map[int]*oauth2.Token
+}
+
+type installation struct {
+	ID      int `json:"id"`
+	Account struct {
+		Login string `json:"login"`
+	} `json:"account"`
+}
+
+type installationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewApp creates an App from its numeric id and PEM encoded private key.
+// Installations maps an owner login to an installation id; owners that are not
+// listed are looked up from the app's installations the first time they are used.
+func NewApp(id int, privateKeyPEM []byte, installations map[string]int) (*App, error) {
+	key, err := parsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	a := App{
+		ID:            id,
+		BaseURL:       defaultAppBaseURL,
+		Installations: make(map[string]int),
+		privateKey:    key,
+		client:        &http.Client{Timeout: 30 * time.Second},
+		tokens:        make(map[int]*oauth2.Token),
+	}
+	for owner, installationID := range installations {
+		a.Installations[strings.ToLower(owner)] = installationID
+	}
+	return &a, nil
+}
+
+func parsePrivateKey(privateKeyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, errors.New("github app private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("github app private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// TokenSource returns an oauth2.TokenSource that yields installation tokens for
+// the installation belonging to owner.
+func (a *App) TokenSource(owner string) oauth2.TokenSource {
+	return &appTokenSource{app: a, owner: owner}
+}
+
+type appTokenSource struct {
+	app   *App
+	owner string
+}
+
+func (t *appTokenSource) Token() (*oauth2.Token, error) {
+	installationID, err := t.app.InstallationID(t.owner)
+	if err != nil {
+		return nil, err
+	}
+	return t.app.InstallationToken(installationID)
+}
+
+// InstallationID returns the installation id of the app for owner.
+func (a *App) InstallationID(owner string) (int, error) {
+	owner = strings.ToLower(owner)
+
+	a.mutex.Lock()
+	installationID, ok := a.Installations[owner]
+	a.mutex.Unlock()
+	if ok {
+		return installationID, nil
+	}
+
+	var installations []installation
+	err := a.appRequest("GET", "app/installations?per_page=100", &installations)
+	if err != nil {
+		return 0, err
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	for _, i := range installations {
+		a.Installations[strings.ToLower(i.Account.Login)] = i.ID
+	}
+
+	installationID, ok = a.Installations[owner]
+	if !ok {
+		return 0, fmt.Errorf("github app %d is not installed for %s", a.ID, owner)
+	}
+	return installationID, nil
+}
+
+// InstallationToken returns a cached access token for the installation, asking
+// GitHub for a new one when there is none or it is about to expire.
+func (a *App) InstallationToken(installationID int) (*oauth2.Token, error) {
+	a.mutex.Lock()
+	token, ok := a.tokens[installationID]
+	a.mutex.Unlock()
+	if ok && time.Now().Add(tokenRefreshMargin).Before(token.Expiry) {
+		return token, nil
+	}
+
+	var response installationToken
+	err := a.appRequest("POST", "app/installations/"+strconv.Itoa(installationID)+"/access_tokens", &response)
+	if err != nil {
+		return nil, err
+	}
+
+	token = &oauth2.Token{
+		AccessToken: response.Token,
+		TokenType:   "token",
+		Expiry:      response.ExpiresAt,
+	}
+
+	a.mutex.Lock()
+	a.tokens[installationID] = token
+	a.mutex.Unlock()
+
+	return token, nil
+}
+
+// appRequest makes a request authenticated as the app itself rather than as one
+// of its installations and decodes the JSON response into v.
+func (a *App) appRequest(method string, path string, v interface{}) error {
+	jwt, err := a.signedJWT(time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(a.BaseURL, "/")+"/"+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github.machine-man-preview+json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("github app request %s %s failed: %s", method, path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// signedJWT builds the RS256 signed JWT GitHub expects when authenticating as
+// an app. The issued-at time is backdated to allow for clock drift.
+func (a *App) signedJWT(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": int64(a.ID),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString(base64.RawURLEncoding.EncodeToString(header))
+	buffer.WriteString(".")
+	buffer.WriteString(base64.RawURLEncoding.EncodeToString(claims))
+
+	hashed := sha256.Sum256(buffer.Bytes())
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.privateKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", err
+	}
+
+	buffer.WriteString(".")
+	buffer.WriteString(base64.RawURLEncoding.EncodeToString(signature))
+	return buffer.String(), nil
+}
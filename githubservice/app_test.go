@@ -0,0 +1,88 @@
+package githubservice
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestApp(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	privateKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	g.Describe("Github App", func() {
+		var tokenRequests int
+		var server *httptest.Server
+
+		g.BeforeEach(func() {
+			tokenRequests = 0
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				switch r.URL.Path {
+				case "/app/installations":
+					fmt.Fprint(w, `[{"id": 42, "account": {"login": "RobotsAndPencils"}}]`)
+				case "/app/installations/42/access_tokens":
+					tokenRequests++
+					fmt.Fprintf(w, `{"token": "token-%d", "expires_at": "%s"}`, tokenRequests, time.Now().Add(time.Hour).Format(time.RFC3339))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+		})
+
+		g.AfterEach(func() {
+			server.Close()
+		})
+
+		g.It("Should map an organization to its installation", func() {
+			app, err := NewApp(1, privateKeyPEM, nil)
+			Expect(err).To(BeNil())
+			app.BaseURL = server.URL
+
+			installationID, err := app.InstallationID("robotsandpencils")
+			Expect(err).To(BeNil())
+			Expect(installationID).To(Equal(42))
+
+			_, err = app.InstallationID("someoneelse")
+			Expect(err).ToNot(BeNil())
+		})
+
+		g.It("Should cache installation tokens until they are about to expire", func() {
+			app, _ := NewApp(1, privateKeyPEM, map[string]int{"RobotsAndPencils": 42})
+			app.BaseURL = server.URL
+
+			first, err := app.TokenSource("RobotsAndPencils").Token()
+			Expect(err).To(BeNil())
+			second, _ := app.TokenSource("RobotsAndPencils").Token()
+			Expect(second.AccessToken).To(Equal(first.AccessToken))
+			Expect(tokenRequests).To(Equal(1))
+
+			app.tokens[42].Expiry = time.Now().Add(time.Minute)
+			third, _ := app.TokenSource("RobotsAndPencils").Token()
+			Expect(third.AccessToken).To(Equal("token-2"))
+		})
+
+		g.It("Should reject a private key that is not PEM encoded", func() {
+			_, err := NewApp(1, []byte("not a key"), nil)
+			Expect(err).ToNot(BeNil())
+		})
+	})
+}
@@ -0,0 +1,307 @@
+package githubservice
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// LaneInterval is a stretch of time an open issue spent in one lane.
+type LaneInterval struct {
+	Lane  string
+	Start time.Time
+	End   time.Time
+}
+
+func (i LaneInterval) Duration() time.Duration {
+	return i.End.Sub(i.Start)
+}
+
+// MaxIssueHistories is how many issues IssueHistories loads the events of, one
+// request or more each, so a busy repository can't use up the rate limit.
+const MaxIssueHistories = 200
+
+// IssueHistory is the path an issue took across the board, rebuilt from its events.
+// Since is the start of the period the history was loaded for, if there is one.
+type IssueHistory struct {
+	Issue     github.Issue
+	Intervals []LaneInterval
+	Since     time.Time
+}
+
+// TimeInLanes adds up the time the issue spent in each lane since the start of the
+// period, counting every visit.
+func (h IssueHistory) TimeInLanes() map[string]time.Duration {
+	times := make(map[string]time.Duration)
+	for _, interval := range h.Intervals {
+		if interval.Start.Before(h.Since) {
+			interval.Start = h.Since
+		}
+		if interval.End.After(interval.Start) {
+			times[interval.Lane] += interval.Duration()
+		}
+	}
+	return times
+}
+
+// LeadTime is the time from opening the issue to closing it. It is false for open issues.
+func (h IssueHistory) LeadTime() (time.Duration, bool) {
+	if h.Issue.ClosedAt == nil || h.Issue.CreatedAt == nil {
+		return 0, false
+	}
+	return h.Issue.ClosedAt.Sub(*h.Issue.CreatedAt), true
+}
+
+// CycleTime is the time from the issue first leaving the catch-all lane to closing it.
+// It is false for open issues and for issues closed straight from the catch-all lane.
+func (h IssueHistory) CycleTime(lanes []Lane) (time.Duration, bool) {
+	if h.Issue.ClosedAt == nil {
+		return 0, false
+	}
+	for _, interval := range h.Intervals {
+		lane, err := FindLane(lanes, interval.Lane)
+		if err == nil && !lane.IsCatchAll() {
+			return h.Issue.ClosedAt.Sub(interval.Start), true
+		}
+	}
+	return 0, false
+}
+
+// ReplayLaneHistory walks the label and state events of an issue in order and works
+// out which lane it was in at each point. Time while the issue is closed is not
+// counted, and the last interval of an open issue runs until now.
+func ReplayLaneHistory(lanes []Lane, issue github.Issue, events []github.IssueEvent, now time.Time) []LaneInterval {
+	sort.Sort(issueEventsByTime(events))
+
+	var intervals []LaneInterval
+	var labels []github.Label
+	open := true
+	start := now
+	if issue.CreatedAt != nil {
+		start = *issue.CreatedAt
+	}
+	current := laneForLabels(lanes, labels)
+
+	// finish closes the interval in progress at t, if the issue is open.
+	finish := func(t time.Time) {
+		if open && current != "" && t.After(start) {
+			intervals = append(intervals, LaneInterval{Lane: current, Start: start, End: t})
+		}
+		start = t
+	}
+
+	for _, event := range events {
+		if event.Event == nil || event.CreatedAt == nil {
+			continue
+		}
+		at := *event.CreatedAt
+
+		switch *event.Event {
+		case "labeled":
+			if event.Label != nil && event.Label.Name != nil {
+				labels = append(withoutLabel(labels, *event.Label.Name), *event.Label)
+			}
+		case "unlabeled":
+			if event.Label != nil && event.Label.Name != nil {
+				labels = withoutLabel(labels, *event.Label.Name)
+			}
+		case "closed":
+			finish(at)
+			open = false
+			continue
+		case "reopened":
+			finish(at)
+			open = true
+			continue
+		default:
+			continue
+		}
+
+		if lane := laneForLabels(lanes, labels); lane != current {
+			finish(at)
+			current = lane
+		}
+	}
+
+	if open {
+		finish(now)
+	}
+	return mergeIntervals(intervals)
+}
+
+// mergeIntervals joins back to back intervals in the same lane, which happen when a
+// lane change is undone before anything else moves.
+func mergeIntervals(intervals []LaneInterval) []LaneInterval {
+	var merged []LaneInterval
+	for _, interval := range intervals {
+		last := len(merged) - 1
+		if last >= 0 && merged[last].Lane == interval.Lane && merged[last].End.Equal(interval.Start) {
+			merged[last].End = interval.End
+			continue
+		}
+		merged = append(merged, interval)
+	}
+	return merged
+}
+
+func laneForLabels(lanes []Lane, labels []github.Label) string {
+	lane := LaneForIssue(lanes, github.Issue{Labels: labels})
+	if lane == nil {
+		return ""
+	}
+	return lane.Name
+}
+
+func withoutLabel(labels []github.Label, name string) []github.Label {
+	var remaining []github.Label
+	for _, label := range labels {
+		if label.Name != nil && !strings.EqualFold(*label.Name, name) {
+			remaining = append(remaining, label)
+		}
+	}
+	return remaining
+}
+
+type issueEventsByTime []github.IssueEvent
+
+func (e issueEventsByTime) Len() int      { return len(e) }
+func (e issueEventsByTime) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
+func (e issueEventsByTime) Less(i, j int) bool {
+	if e[i].CreatedAt == nil || e[j].CreatedAt == nil {
+		return e[j].CreatedAt != nil
+	}
+	return e[i].CreatedAt.Before(*e[j].CreatedAt)
+}
+
+// Percentile interpolates the pth percentile (0-100) of durations.
+func Percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration{}, durations...)
+	sort.Sort(durationSorter(sorted))
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	fraction := rank - float64(lower)
+	return sorted[lower] + time.Duration(fraction*float64(sorted[upper]-sorted[lower]))
+}
+
+// OutlierThreshold is the Tukey fence: anything longer than the 75th percentile plus
+// one and a half interquartile ranges stands out from the rest.
+func OutlierThreshold(durations []time.Duration) time.Duration {
+	q1 := Percentile(durations, 25)
+	q3 := Percentile(durations, 75)
+	return q3 + (q3-q1)*3/2
+}
+
+type durationSorter []time.Duration
+
+func (d durationSorter) Len() int           { return len(d) }
+func (d durationSorter) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d durationSorter) Less(i, j int) bool { return d[i] < d[j] }
+
+func (g *GithubService) loadEventsForIssue(owner string, repo string, number int) ([]github.IssueEvent, error) {
+	var client = g.obtainAuthenticatedGithubClient()
+	var allEvents []github.IssueEvent
+	var e error
+	opt := &github.ListOptions{PerPage: 100}
+
+	for {
+		events, resp, err := client.Issues.ListIssueEvents(owner, repo, number, opt)
+
+		if err != nil {
+			e = err
+			break
+		}
+
+		allEvents = append(allEvents, events...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opt.Page = resp.NextPage
+	}
+
+	return allEvents, e
+}
+
+func (g *GithubService) loadIssuesUpdatedSince(owner string, repo string, since time.Time) ([]github.Issue, error) {
+	var client = g.obtainAuthenticatedGithubClient()
+	var allIssues []github.Issue
+	var e error
+	opt := &github.IssueListByRepoOptions{
+		State:       "all",
+		Since:       since,
+		Sort:        "updated",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		issues, resp, err := client.Issues.ListByRepo(owner, repo, opt)
+
+		if err != nil {
+			e = err
+			break
+		}
+
+		for _, issue := range issues {
+			if issue.PullRequestLinks == nil {
+				allIssues = append(allIssues, issue)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opt.ListOptions.Page = resp.NextPage
+	}
+
+	return allIssues, e
+}
+
+// IssueHistories rebuilds the lane history of every issue in repo that was open or
+// changed since the given time, skipping issues that were closed before it. Only the
+// MaxIssueHistories most recently updated issues are rebuilt, and the number of
+// issues left out is returned with them.
+func (g *GithubService) IssueHistories(owner string, repo string, since time.Time) ([]IssueHistory, int, error) {
+	issues, err := g.loadIssuesUpdatedSince(owner, repo, since)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var inPeriod []github.Issue
+	for _, issue := range issues {
+		if issue.ClosedAt == nil || !issue.ClosedAt.Before(since) {
+			inPeriod = append(inPeriod, issue)
+		}
+	}
+	skipped := 0
+	if len(inPeriod) > MaxIssueHistories {
+		skipped = len(inPeriod) - MaxIssueHistories
+		inPeriod = inPeriod[:MaxIssueHistories]
+	}
+
+	lanes := g.lanes()
+	now := time.Now()
+	var histories []IssueHistory
+	for _, issue := range inPeriod {
+		events, err := g.loadEventsForIssue(owner, repo, *issue.Number)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		histories = append(histories, IssueHistory{
+			Issue:     issue,
+			Intervals: ReplayLaneHistory(lanes, issue, events, now),
+			Since:     since,
+		})
+	}
+	return histories, skipped, nil
+}
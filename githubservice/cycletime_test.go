@@ -0,0 +1,99 @@
+package githubservice
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+	"github.com/google/go-github/github"
+	. "github.com/onsi/gomega"
+)
+
+func issueEvent(kind string, label string, at time.Time) github.IssueEvent {
+	event := github.IssueEvent{Event: &kind, CreatedAt: &at}
+	if label != "" {
+		event.Label = &github.Label{Name: &label}
+	}
+	return event
+}
+
+func TestCycleTime(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	opened := time.Date(2016, time.March, 1, 9, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	g.Describe("Lane history", func() {
+		g.It("Should time each lane an issue passed through", func() {
+			closed := opened.Add(6 * day)
+			issue := github.Issue{CreatedAt: &opened, ClosedAt: &closed}
+			events := []github.IssueEvent{
+				issueEvent("closed", "", closed),
+				issueEvent("labeled", "2 - In Progress", opened.Add(1*day)),
+				issueEvent("unlabeled", "2 - In Progress", opened.Add(4*day)),
+				issueEvent("labeled", "4 - Ready for QA", opened.Add(4*day)),
+			}
+
+			intervals := ReplayLaneHistory(DefaultLanes, issue, events, closed.Add(day))
+
+			Expect(intervals).To(HaveLen(3))
+			Expect(intervals[0].Lane).To(Equal("Backlog"))
+			Expect(intervals[0].Duration()).To(Equal(1 * day))
+			Expect(intervals[1].Lane).To(Equal("In Progress"))
+			Expect(intervals[1].Duration()).To(Equal(3 * day))
+			Expect(intervals[2].Lane).To(Equal("Ready for QA"))
+			Expect(intervals[2].Duration()).To(Equal(2 * day))
+
+			history := IssueHistory{Issue: issue, Intervals: intervals}
+			leadTime, _ := history.LeadTime()
+			cycleTime, _ := history.CycleTime(DefaultLanes)
+			Expect(leadTime).To(Equal(6 * day))
+			Expect(cycleTime).To(Equal(5 * day))
+		})
+
+		g.It("Should not count the time an issue was closed", func() {
+			issue := github.Issue{CreatedAt: &opened}
+			events := []github.IssueEvent{
+				issueEvent("labeled", "in progress", opened),
+				issueEvent("closed", "", opened.Add(1*day)),
+				issueEvent("reopened", "", opened.Add(5*day)),
+			}
+
+			intervals := ReplayLaneHistory(DefaultLanes, issue, events, opened.Add(7*day))
+
+			history := IssueHistory{Issue: issue, Intervals: intervals}
+			Expect(history.TimeInLanes()).To(Equal(map[string]time.Duration{"In Progress": 3 * day}))
+		})
+
+		g.It("Should only count the time since the start of the period", func() {
+			issue := github.Issue{CreatedAt: &opened}
+			events := []github.IssueEvent{
+				issueEvent("labeled", "in progress", opened.Add(2*day)),
+			}
+
+			intervals := ReplayLaneHistory(DefaultLanes, issue, events, opened.Add(10*day))
+
+			history := IssueHistory{Issue: issue, Intervals: intervals, Since: opened.Add(6 * day)}
+			Expect(history.TimeInLanes()).To(Equal(map[string]time.Duration{"In Progress": 4 * day}))
+		})
+	})
+
+	g.Describe("Percentiles", func() {
+		durations := []time.Duration{4 * day, 1 * day, 3 * day, 2 * day, 5 * day}
+
+		g.It("Should find the median and the 85th percentile", func() {
+			Expect(Percentile(durations, 50)).To(Equal(3 * day))
+			Expect(Percentile(durations, 85)).To(BeNumerically("~", 4*day+2*day/5, time.Second))
+		})
+
+		g.It("Should only call far longer durations outliers", func() {
+			threshold := OutlierThreshold(append(durations, 30*day))
+
+			Expect(threshold).To(BeNumerically(">", 5*day))
+			Expect(threshold).To(BeNumerically("<", 30*day))
+		})
+	})
+}
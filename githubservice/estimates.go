@@ -0,0 +1,171 @@
+package githubservice
+
+import (
+	"bufio"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// EstimateConfig says where the story points of an issue are written down.
+//
+// Sizes maps whole labels to points, such as "size/S": 1. Patterns are regular
+// expressions matched against each label, whose first group is the number of
+// points. FrontMatter lists the keys read from a block of "key: value" lines
+// between two "---" lines at the top of the issue body.
+type EstimateConfig struct {
+	Sizes       map[string]float64 `json:"sizes"`
+	Patterns    []string           `json:"patterns"`
+	FrontMatter []string           `json:"frontMatter"`
+}
+
+// By default labels like "points: 3", "3 points", "3 pts" or "sp 5" and a "points"
+// or "estimate" key in the front matter are estimates.
+var DefaultEstimateConfig = EstimateConfig{
+	Patterns: []string{
+		`(?i)^\s*(?:story\s*)?(?:points?|pts?|sp|estimate)\s*[:=/]?\s*(\d+(?:\.\d+)?)\s*$`,
+		`(?i)^\s*(\d+(?:\.\d+)?)\s*(?:story\s*)?(?:points?|pts?|sp)\s*$`,
+	},
+	FrontMatter: []string{"points", "estimate"},
+}
+
+// Estimator reads the story points of issues.
+type Estimator struct {
+	sizes       map[string]float64
+	patterns    []*regexp.Regexp
+	frontMatter []string
+}
+
+// NewEstimator compiles an estimate configuration. Patterns and FrontMatter that
+// aren't configured come from DefaultEstimateConfig, so configuring only sizes still
+// reads labels like "3 pts".
+func NewEstimator(config EstimateConfig) (*Estimator, error) {
+	if len(config.Patterns) == 0 {
+		config.Patterns = DefaultEstimateConfig.Patterns
+	}
+	if len(config.FrontMatter) == 0 {
+		config.FrontMatter = DefaultEstimateConfig.FrontMatter
+	}
+
+	e := Estimator{sizes: make(map[string]float64)}
+	for label, points := range config.Sizes {
+		e.sizes[strings.ToLower(strings.TrimSpace(label))] = points
+	}
+	for _, pattern := range config.Patterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		e.patterns = append(e.patterns, compiled)
+	}
+	for _, key := range config.FrontMatter {
+		e.frontMatter = append(e.frontMatter, strings.ToLower(key))
+	}
+	return &e, nil
+}
+
+var defaultEstimator, _ = NewEstimator(DefaultEstimateConfig)
+
+// Estimate finds the story points of an issue in its labels and then in the front
+// matter of its body. It is false when the issue has no estimate.
+func (e *Estimator) Estimate(issue github.Issue) (float64, bool) {
+	for _, label := range issue.Labels {
+		if label.Name == nil {
+			continue
+		}
+		if points, ok := e.sizes[strings.ToLower(strings.TrimSpace(*label.Name))]; ok {
+			return points, true
+		}
+		for _, pattern := range e.patterns {
+			if match := pattern.FindStringSubmatch(*label.Name); len(match) > 1 {
+				points, err := strconv.ParseFloat(match[1], 64)
+				if err == nil {
+					return points, true
+				}
+			}
+		}
+	}
+
+	if issue.Body != nil && len(e.frontMatter) > 0 {
+		values := frontMatter(*issue.Body)
+		for _, key := range e.frontMatter {
+			if value, ok := values[key]; ok {
+				if points, err := strconv.ParseFloat(value, 64); err == nil {
+					return points, true
+				}
+				if points, ok := e.sizes[strings.ToLower(value)]; ok {
+					return points, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+// frontMatter reads the "key: value" lines between the "---" lines at the top of body.
+func frontMatter(body string) map[string]string {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(strings.TrimLeft(body, " \r\n")))
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "---" {
+		return values
+	}
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "---" {
+			return values
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) == 2 {
+			values[strings.ToLower(strings.TrimSpace(parts[0]))] = strings.TrimSpace(parts[1])
+		}
+	}
+	// No closing line, so it wasn't front matter after all.
+	return make(map[string]string)
+}
+
+// ParseEstimate finds the story points of an issue with the default estimate configuration.
+func ParseEstimate(issue github.Issue) (float64, bool) {
+	return defaultEstimator.Estimate(issue)
+}
+
+// EstimateTotals adds up the points of a set of issues.
+type EstimateTotals struct {
+	Issues      int
+	Points      float64
+	Unestimated []github.Issue
+}
+
+func (t *EstimateTotals) Add(e *Estimator, issue github.Issue) {
+	t.Issues++
+	if points, ok := e.Estimate(issue); ok {
+		t.Points += points
+	} else {
+		t.Unestimated = append(t.Unestimated, issue)
+	}
+}
+
+// SumEstimates totals the points of issues, overall and for each assignee. Issues
+// without an assignee are totalled under the empty string. Pull requests in the list
+// are left out, as they carry out issues rather than being work of their own.
+func SumEstimates(e *Estimator, issues []github.Issue) (EstimateTotals, map[string]*EstimateTotals) {
+	var total EstimateTotals
+	byAssignee := make(map[string]*EstimateTotals)
+	for _, issue := range issues {
+		if issue.PullRequestLinks != nil {
+			continue
+		}
+		total.Add(e, issue)
+
+		login := ""
+		if issue.Assignee != nil && issue.Assignee.Login != nil {
+			login = *issue.Assignee.Login
+		}
+		if byAssignee[login] == nil {
+			byAssignee[login] = &EstimateTotals{}
+		}
+		byAssignee[login].Add(e, issue)
+	}
+	return total, byAssignee
+}
@@ -1,10 +1,12 @@
 package githubservice
 
 import (
+	"errors"
+	"github.com/RobotsAndPencils/marvin/calendar"
 	"github.com/google/go-github/github"
 	"golang.org/x/net/context"
 	"golang.org/x/oauth2"
-	"log"
+	"net/http"
 	"sort"
 	"strings"
 	"time"
@@ -12,6 +14,12 @@ import (
 
 type GithubService struct {
 	PersonalAccessToken string
+	TokenSource         oauth2.TokenSource
+	Lanes               []Lane
+
+	// Calendar counts the age of pull requests in business days. Without one ages
+	// are in calendar days.
+	Calendar *calendar.Calendar
 }
 
 func New(personalAccessToken string) *GithubService {
@@ -21,6 +29,15 @@ func New(personalAccessToken string) *GithubService {
 	return &g
 }
 
+// NewWithTokenSource creates a service that authenticates with tokens from
+// tokenSource, such as the installation tokens handed out by an App.
+func NewWithTokenSource(tokenSource oauth2.TokenSource) *GithubService {
+	g := GithubService{
+		TokenSource: tokenSource,
+	}
+	return &g
+}
+
 type TokenSource struct {
 	AccessToken string
 }
@@ -33,40 +50,18 @@ func (t *TokenSource) Token() (*oauth2.Token, error) {
 }
 
 func (g *GithubService) obtainAuthenticatedGithubClient() (c *github.Client) {
-	tokenSource := &TokenSource{
+	var tokenSource oauth2.TokenSource = &TokenSource{
 		AccessToken: g.PersonalAccessToken,
 	}
+	if g.TokenSource != nil {
+		tokenSource = g.TokenSource
+	}
 	oauthClient := oauth2.NewClient(context.TODO(), tokenSource)
 	return github.NewClient(oauthClient)
 }
 
 func (g *GithubService) loadIssuesForAssignee(owner string, assignee string) ([]github.Issue, error) {
-	var client = g.obtainAuthenticatedGithubClient()
-	var all []github.Issue
-	var e error
-	opt := &github.SearchOptions{
-
-		ListOptions: github.ListOptions{PerPage: 100},
-	}
-
-	for {
-		issueSearchResults, resp, err := client.Search.Issues("user:"+owner+" assignee:"+assignee, opt)
-
-		if err != nil {
-			e = err
-			break
-		}
-
-		all = append(all, issueSearchResults.Issues...)
-
-		if resp.NextPage == 0 {
-			break
-		}
-
-		opt.ListOptions.Page = resp.NextPage
-	}
-
-	return all, e
+	return g.searchIssues("user:" + owner + " assignee:" + assignee)
 }
 
 func (g *GithubService) loadIssuesForRepo(owner string, repo string, assigned string) ([]github.Issue, error) {
@@ -98,11 +93,13 @@ func (g *GithubService) loadIssuesForRepo(owner string, repo string, assigned st
 	return allIssues, e
 }
 
-func (g *GithubService) loadCommitsForRepo(owner string, repo string, committer string, timeLimit time.Time) ([]github.RepositoryCommit, error) {
+// loadCommitsForRepo lists the commits on branch since timeLimit.
+func (g *GithubService) loadCommitsForRepo(owner string, repo string, branch string, committer string, timeLimit time.Time) ([]github.RepositoryCommit, error) {
 	var client = g.obtainAuthenticatedGithubClient()
 	var allCommits []github.RepositoryCommit
 	var e error
 	opt := &github.CommitsListOptions{
+		SHA:         branch,
 		Since:       timeLimit,
 		ListOptions: github.ListOptions{PerPage: 500},
 	}
@@ -127,6 +124,21 @@ func (g *GithubService) loadCommitsForRepo(owner string, repo string, committer
 	return allCommits, e
 }
 
+// loadDefaultBranch asks GitHub which branch a repository treats as its default,
+// since not every repository still uses master.
+func (g *GithubService) loadDefaultBranch(owner string, repo string) (string, error) {
+	var client = g.obtainAuthenticatedGithubClient()
+
+	repository, _, err := client.Repositories.Get(owner, repo)
+	if err != nil {
+		return "", err
+	}
+	if repository.DefaultBranch == nil {
+		return "master", nil
+	}
+	return *repository.DefaultBranch, nil
+}
+
 func (g *GithubService) loadReposForOrganization(owner string) ([]github.Repository, error) {
 	var client = g.obtainAuthenticatedGithubClient()
 	var allRepos []github.Repository
@@ -156,35 +168,20 @@ func (g *GithubService) loadReposForOrganization(owner string) ([]github.Reposit
 	return allRepos, e
 }
 
-func (g *GithubService) loadPRsForRepo(owner string, repo string) ([]github.PullRequest, error) {
+// loadPRsForCommit finds the pull requests a commit belongs to. go-github has no
+// call for this endpoint yet so the request is made by hand.
+func (g *GithubService) loadPRsForCommit(owner string, repo string, sha string) ([]github.PullRequest, error) {
 	var client = g.obtainAuthenticatedGithubClient()
-	var allPRs []github.PullRequest
-	var e error
-	opt := &github.PullRequestListOptions{
-		State: "open",
-		// TODO: These params should be available but sadly they don't pass the compiler
-		//		Sort: 		"long-running",
-		//		Direction: 	"desc",
-		ListOptions: github.ListOptions{PerPage: 100},
-	}
-
-	for {
-		pullRequests, resp, err := client.PullRequests.List(owner, repo, opt)
-		if err != nil {
-			e = err
-			break
-		}
-
-		allPRs = append(allPRs, pullRequests...)
 
-		if resp.NextPage == 0 {
-			break
-		}
-
-		opt.ListOptions.Page = resp.NextPage
+	req, err := client.NewRequest("GET", "repos/"+owner+"/"+repo+"/commits/"+sha+"/pulls", nil)
+	if err != nil {
+		return nil, err
 	}
+	req.Header.Set("Accept", "application/vnd.github.groot-preview+json")
 
-	return allPRs, e
+	var pullRequests []github.PullRequest
+	_, err = client.Do(req, &pullRequests)
+	return pullRequests, err
 }
 
 func (g *GithubService) loadCommitsFromAllRepoPRs(owner string, repo string, timeLimit time.Time) ([]github.RepositoryCommit, error) {
@@ -256,8 +253,9 @@ func (g *GithubService) loadActiveReposForOrganization(owner string, days int) (
 		e = err
 	}
 
+	since := g.daysAgo(days)
 	for _, repo := range allRepos {
-		if time.Since(repo.PushedAt.Time).Hours() <= float64(days)*24 {
+		if !repo.PushedAt.Time.Before(since) {
 			activeRepos = append(activeRepos, repo)
 		}
 	}
@@ -266,7 +264,7 @@ func (g *GithubService) loadActiveReposForOrganization(owner string, days int) (
 	return activeRepos, e
 }
 
-func (g *GithubService) loadOpenPRsForOrganization(owner string, daysPROpen int, daysSinceLastProjectActivity int) ([]github.PullRequest, error) {
+func (g *GithubService) loadOpenPRsForOrganization(owner string, daysPROpen int, daysSinceLastProjectActivity int, filter PullRequestFilter) ([]github.PullRequest, error) {
 	var activeRepos []github.Repository
 	var e error
 
@@ -275,22 +273,46 @@ func (g *GithubService) loadOpenPRsForOrganization(owner string, daysPROpen int,
 		e = err
 	}
 
+	for _, team := range filter.Teams {
+		members, err := g.TeamMembers(owner, team)
+		if err != nil {
+			return nil, err
+		}
+		// Without members the team would add no authors and so filter nothing out.
+		if len(members) == 0 {
+			return nil, errors.New("The team " + team + " in " + owner + " has no members")
+		}
+		filter.Authors = append(filter.Authors, members...)
+	}
+
 	var allOpenPRs []github.PullRequest
 	for _, repo := range activeRepos {
-		pullRequests, err := g.loadPRsForRepo(owner, *repo.Name)
+		var topics []string
+		if filter.needsTopics() {
+			topics, err = g.loadTopicsForRepo(owner, *repo.Name)
+			if err != nil {
+				e = err
+				break
+			}
+		}
+		if !filter.MatchesRepo(*repo.Name, topics) {
+			continue
+		}
+
+		pullRequests, err := g.loadListedPRsForRepo(owner, *repo.Name)
 		if err != nil {
 			e = err
 			break
 		}
 
 		for _, pullRequest := range pullRequests {
-			var numberOfDays = time.Since(*pullRequest.CreatedAt).Hours() / 24
+			var numberOfDays = g.ageInDays(*pullRequest.CreatedAt)
 			if numberOfDays < float64(daysPROpen) {
-				log.Printf("Skipping PR open for %f days", numberOfDays)
-				break // These PRs are too new for us to care about
-			} else {
-				log.Printf("Adding PR open for %f days", numberOfDays)
-				allOpenPRs = append(allOpenPRs, pullRequest)
+				continue // These PRs are too new for us to care about
+			}
+			draft := pullRequest.Draft != nil && *pullRequest.Draft
+			if filter.MatchesPullRequest(pullRequest.PullRequest, pullRequest.labelNames(), draft) {
+				allOpenPRs = append(allOpenPRs, pullRequest.PullRequest)
 			}
 		}
 	}
@@ -300,6 +322,11 @@ func (g *GithubService) loadOpenPRsForOrganization(owner string, daysPROpen int,
 	return allOpenPRs, e
 }
 
+// ageInDays is how long ago t was, in business days when the service has a calendar.
+func (g *GithubService) ageInDays(t time.Time) float64 {
+	return g.daysBetween(t, time.Now())
+}
+
 // RepositoryNameSorter sorts Repository by name.
 type RepositoryNameSorter []github.Repository
 
@@ -339,10 +366,18 @@ func (g *GithubService) makeIssueList(owner string, repo string, assigned string
 	return sprintIssues, err
 }
 
-func (g *GithubService) makeCommitsList(owner string, repo string, committer string, lambda func(github.RepositoryCommit, []github.RepositoryCommit) bool, days int) (map[string][]github.RepositoryCommit, int, error) {
+// BranchCommits are the commits found on one branch of a repository.
+type BranchCommits struct {
+	Branch  string
+	Commits []github.RepositoryCommit
+}
+
+// makeCommitsList finds the commits on branch that lambda rejects, for one repo or for
+// every active repo when repo is empty. An empty branch means each repository's default branch.
+func (g *GithubService) makeCommitsList(owner string, repo string, branch string, committer string, lambda func(github.RepositoryCommit, []github.RepositoryCommit) bool, days int) (map[string]BranchCommits, int, error) {
 
 	totalCommits := 0
-	repoToMasterCommits := make(map[string][]github.RepositoryCommit)
+	repoToMasterCommits := make(map[string]BranchCommits)
 
 	if repo == "" {
 		//summary of commits from all repos
@@ -353,37 +388,57 @@ func (g *GithubService) makeCommitsList(owner string, repo string, committer str
 
 		for _, repository := range repositories {
 			repoName := *repository.Name
-			masterCommits, totalRepoCommits, err := g.masterCommitsForSingleRepo(owner, repoName, committer, lambda, days)
+			repoBranch := branch
+			if repoBranch == "" && repository.DefaultBranch != nil {
+				repoBranch = *repository.DefaultBranch
+			} else if repoBranch == "" {
+				repoBranch = "master"
+			}
+			masterCommits, totalRepoCommits, err := g.masterCommitsForSingleRepo(owner, repoName, repoBranch, committer, lambda, days)
 
+			if isMissingBranch(err) {
+				// Not every repository has the branch that was asked for.
+				continue
+			}
 			if err != nil {
 				return nil, 0, err
 			}
 
 			if len(masterCommits) > 0 {
-				repoToMasterCommits[repoName] = masterCommits
+				repoToMasterCommits[repoName] = BranchCommits{Branch: repoBranch, Commits: masterCommits}
 				totalCommits += totalRepoCommits
 			}
 		}
 	} else {
 		//single repo query
-		masterCommits, totalRepoCommits, err := g.masterCommitsForSingleRepo(owner, repo, committer, lambda, days)
+		if branch == "" {
+			defaultBranch, err := g.loadDefaultBranch(owner, repo)
+			if err != nil {
+				return nil, 0, err
+			}
+			branch = defaultBranch
+		}
+		masterCommits, totalRepoCommits, err := g.masterCommitsForSingleRepo(owner, repo, branch, committer, lambda, days)
 
 		if err != nil {
 			return nil, 0, err
 		}
 
-		repoToMasterCommits[repo] = masterCommits
+		repoToMasterCommits[repo] = BranchCommits{Branch: branch, Commits: masterCommits}
 		totalCommits += totalRepoCommits
 	}
 
 	return repoToMasterCommits, totalCommits, nil
 }
 
-func (g *GithubService) masterCommitsForSingleRepo(owner string, repo string, committer string, lambda func(github.RepositoryCommit, []github.RepositoryCommit) bool, days int) ([]github.RepositoryCommit, int, error) {
+func (g *GithubService) masterCommitsForSingleRepo(owner string, repo string, branch string, committer string, lambda func(github.RepositoryCommit, []github.RepositoryCommit) bool, days int) ([]github.RepositoryCommit, int, error) {
 
 	var timeLimit = time.Now().AddDate(0, 0, -days)
 
-	commits, err := g.loadCommitsForRepo(owner, repo, committer, timeLimit)
+	commits, err := g.loadCommitsForRepo(owner, repo, branch, committer, timeLimit)
+	if err != nil {
+		return nil, 0, err
+	}
 	allPRCommits, err := g.loadCommitsFromAllRepoPRs(owner, repo, timeLimit)
 
 	if err != nil {
@@ -403,6 +458,16 @@ func (g *GithubService) masterCommitsForSingleRepo(owner string, repo string, co
 	return masterCommits, len(commits), err
 }
 
+// isMissingBranch is true for the errors GitHub gives when listing the commits of a
+// branch a repository doesn't have, or of an empty repository that has no branches.
+func isMissingBranch(err error) bool {
+	response, ok := err.(*github.ErrorResponse)
+	if !ok || response.Response == nil {
+		return false
+	}
+	return response.Response.StatusCode == http.StatusNotFound || response.Response.StatusCode == http.StatusConflict
+}
+
 func (g *GithubService) AssignedTo(owner string, repo string, login string) ([]github.Issue, error) {
 	if repo == "*" {
 		return g.loadIssuesForAssignee(owner, login)
@@ -412,36 +477,65 @@ func (g *GithubService) AssignedTo(owner string, repo string, login string) ([]g
 	}
 }
 
+// IssuesInLane lists the open issues of repo that LaneForIssue puts in the lane called
+// name, so the lane commands agree with the board and /move.
+func (g *GithubService) IssuesInLane(owner string, repo string, lanes []Lane, name string) ([]github.Issue, error) {
+	lane, err := FindLane(lanes, name)
+	if err != nil {
+		return nil, err
+	}
+	return g.makeIssueList(owner, repo, "", func(issue github.Issue) bool {
+		found := LaneForIssue(lanes, issue)
+		return found != nil && found.Name == lane.Name
+	})
+}
+
 func (g *GithubService) Sprint(owner string, repo string) ([]github.Issue, error) {
-	return g.makeIssueList(owner, repo, "", g.isSprintItem)
+	return g.IssuesInLane(owner, repo, g.lanes(), "sprint")
 }
 
 func (g *GithubService) InProgress(owner string, repo string) ([]github.Issue, error) {
-	return g.makeIssueList(owner, repo, "", g.isInProgress)
+	return g.IssuesInLane(owner, repo, g.lanes(), "in progress")
 }
 
 func (g *GithubService) ReadyForQA(owner string, repo string) ([]github.Issue, error) {
-	return g.makeIssueList(owner, repo, "", g.isReadyForQA)
+	return g.IssuesInLane(owner, repo, g.lanes(), "ready for qa")
 }
 
 func (g *GithubService) QAPass(owner string, repo string) ([]github.Issue, error) {
-	return g.makeIssueList(owner, repo, "", g.isQAPass)
+	return g.IssuesInLane(owner, repo, g.lanes(), "qa pass")
 }
 
 func (g *GithubService) Backlog(owner string, repo string) ([]github.Issue, error) {
-	return g.makeIssueList(owner, repo, "", g.isBacklogItem)
+	return g.IssuesInLane(owner, repo, g.lanes(), "backlog")
 }
 
 func (g *GithubService) ReadyForReview(owner string, repo string) ([]github.Issue, error) {
-	return g.makeIssueList(owner, repo, "", g.isReadyForReview)
+	return g.IssuesInLane(owner, repo, g.lanes(), "ready for review")
 }
 
 func (g *GithubService) OpenPullRequests(owner string, daysPROpen int, daysSinceLastProjectActivity int) ([]github.PullRequest, error) {
-	return g.loadOpenPRsForOrganization(owner, daysPROpen, daysSinceLastProjectActivity)
+	return g.loadOpenPRsForOrganization(owner, daysPROpen, daysSinceLastProjectActivity, PullRequestFilter{})
 }
 
-func (g *GithubService) CommitsToMaster(owner string, repo string, days int) (map[string][]github.RepositoryCommit, int, error) {
-	return g.makeCommitsList(owner, repo, "", g.isCommitInList, days)
+func (g *GithubService) FilteredOpenPullRequests(owner string, daysPROpen int, daysSinceLastProjectActivity int, filter PullRequestFilter) ([]github.PullRequest, error) {
+	return g.loadOpenPRsForOrganization(owner, daysPROpen, daysSinceLastProjectActivity, filter)
+}
+
+func (g *GithubService) PullRequestsForCommit(owner string, repo string, sha string) ([]github.PullRequest, error) {
+	return g.loadPRsForCommit(owner, repo, sha)
+}
+
+// CommitsToMaster finds the commits that landed on branch without a pull request. An
+// empty branch checks each repository's default branch, whatever it is called.
+func (g *GithubService) CommitsToMaster(owner string, repo string, branch string, days int) (map[string]BranchCommits, int, error) {
+	return g.makeCommitsList(owner, repo, branch, "", g.isCommitInList, days)
+}
+
+func (g *GithubService) AssignIssue(owner string, repo string, number int, login string) (*github.Issue, error) {
+	var client = g.obtainAuthenticatedGithubClient()
+	issue, _, err := client.Issues.Edit(owner, repo, number, &github.IssueRequest{Assignee: &login})
+	return issue, err
 }
 
 func (g *GithubService) getLabelString(labels []github.Label) string {
@@ -456,48 +550,14 @@ func (g *GithubService) any(issue github.Issue) bool {
 	return true
 }
 
-func (g *GithubService) isBacklogItem(issue github.Issue) bool {
-
-	if g.isSprintItem(issue) || g.isInProgress(issue) || g.isReadyForQA(issue) || g.isQAPass(issue) || g.isDone(issue) {
-		return false
-	} else {
-		return true
-	}
-}
-
 func (g *GithubService) isProductBacklogItem(issue github.Issue) bool {
 	label := g.getLabelString(issue.Labels)
 	return strings.Contains(label, "product") && strings.Contains(label, "backlog")
 }
 
-func (g *GithubService) isSprintItem(issue github.Issue) bool {
-	label := g.getLabelString(issue.Labels)
-	return strings.Contains(label, "sprint")
-}
-
-func (g *GithubService) isInProgress(issue github.Issue) bool {
-	label := g.getLabelString(issue.Labels)
-	return strings.Contains(label, "in") && strings.Contains(label, "progress")
-}
-
-func (g *GithubService) isReadyForQA(issue github.Issue) bool {
-	label := g.getLabelString(issue.Labels)
-	return strings.Contains(label, "ready") && strings.Contains(label, "for") && strings.Contains(label, "qa")
-}
-
-func (g *GithubService) isReadyForReview(issue github.Issue) bool {
-	label := g.getLabelString(issue.Labels)
-	return strings.Contains(label, "ready") && strings.Contains(label, "for") && strings.Contains(label, "review")
-}
-
-func (g *GithubService) isQAPass(issue github.Issue) bool {
-	label := g.getLabelString(issue.Labels)
-	return strings.Contains(label, "qa") && strings.Contains(label, "pass")
-}
-
-func (g *GithubService) isDone(issue github.Issue) bool {
-	label := g.getLabelString(issue.Labels)
-	return strings.Contains(label, "done")
+// isSprintName tells which milestones are sprints.
+func isSprintName(name string) bool {
+	return strings.Contains(strings.ToLower(name), "sprint")
 }
 
 func (g *GithubService) isCommitInList(commit github.RepositoryCommit, commitList []github.RepositoryCommit) bool {
@@ -510,3 +570,14 @@ func (g *GithubService) isCommitInList(commit github.RepositoryCommit, commitLis
 
 	return false
 }
+
+// ActiveRepos lists the names of the repositories pushed to in the last days.
+func (g *GithubService) ActiveRepos(owner string, days int) ([]string, error) {
+	repositories, err := g.loadActiveReposForOrganization(owner, days)
+
+	var names []string
+	for _, repository := range repositories {
+		names = append(names, *repository.Name)
+	}
+	return names, err
+}
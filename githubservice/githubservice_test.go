@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strconv"
 	"testing"
+	"time"
 
 	. "github.com/franela/goblin"
 	. "github.com/onsi/gomega"
@@ -29,7 +30,7 @@ func Test(t *testing.T) {
 		})
 
 		g.It("Should find open pull requests for active repos in RobotsAndPencils", func() {
-			pullRequests, err := s.loadOpenPRsForOrganization("RobotsAndPencils", daysOfActivity, daysOfActivity)
+			pullRequests, err := s.loadOpenPRsForOrganization("RobotsAndPencils", daysOfActivity, daysOfActivity, PullRequestFilter{})
 
 			Expect(pullRequests).ToNot(BeNil())
 			Expect(err).To(BeNil())
@@ -108,21 +109,21 @@ func Test(t *testing.T) {
 		})
 
 		g.It("Should find commits for a repo", func() {
-			commits, err := s.loadCommitsForRepo("RobotsAndPencils", "marvin", "", 30)
+			commits, err := s.loadCommitsForRepo("RobotsAndPencils", "marvin", "", "", time.Now().AddDate(0, 0, -30))
 
 			Expect(commits).ToNot(BeNil())
 			Expect(err).To(BeNil())
 		})
 
 		g.It("Should find PR commits for a repo", func() {
-			commits, err := s.loadCommitsFromAllRepoPRs("RobotsAndPencils", "marvin", 30)
+			commits, err := s.loadCommitsFromAllRepoPRs("RobotsAndPencils", "marvin", time.Now().AddDate(0, 0, -30))
 
 			Expect(commits).ToNot(BeNil())
 			Expect(err).To(BeNil())
 		})
 
 		g.It("Should find commits to master", func() {
-			commits, total, err := s.CommitsToMaster("RobotsAndPencils", "marvin", 30)
+			commits, total, err := s.CommitsToMaster("RobotsAndPencils", "marvin", "", 30)
 
 			Expect(commits).ToNot(BeNil())
 			Expect(total).ToNot(BeNil())
@@ -130,7 +131,7 @@ func Test(t *testing.T) {
 		})
 
 		g.It("Commits to master for all repos", func() {
-			commits, total, err := s.CommitsToMaster("RobotsAndPencils", "", 7)
+			commits, total, err := s.CommitsToMaster("RobotsAndPencils", "", "", 7)
 
 			Expect(commits).ToNot(BeNil())
 			Expect(total).ToNot(BeNil())
@@ -0,0 +1,128 @@
+package githubservice
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// loadMilestonesForRepo pages through the milestones of a repository. The vendored
+// go-github can't page milestones, so the endpoint is called by hand.
+func (g *GithubService) loadMilestonesForRepo(owner string, repo string, state string) ([]github.Milestone, error) {
+	var client = g.obtainAuthenticatedGithubClient()
+	var all []github.Milestone
+	var e error
+	page := 1
+
+	for {
+		req, err := client.NewRequest("GET", "repos/"+owner+"/"+repo+"/milestones?state="+state+"&per_page=100&page="+strconv.Itoa(page), nil)
+		if err != nil {
+			e = err
+			break
+		}
+		var milestones []github.Milestone
+		resp, err := client.Do(req, &milestones)
+
+		if err != nil {
+			e = err
+			break
+		}
+
+		all = append(all, milestones...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		page = resp.NextPage
+	}
+
+	return all, e
+}
+
+// findMilestone looks an open milestone up by its title, ignoring case.
+func (g *GithubService) findMilestone(owner string, repo string, title string) (*github.Milestone, error) {
+	milestones, err := g.loadMilestonesForRepo(owner, repo, "open")
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range milestones {
+		if strings.EqualFold(*milestones[i].Title, strings.TrimSpace(title)) {
+			return &milestones[i], nil
+		}
+	}
+	return nil, errors.New("there is no open milestone called " + title + " in " + repo)
+}
+
+// CreateIssue files a new issue in the given lane. The assignee and milestone
+// title are optional; an empty lane name puts the issue in the backlog.
+func (g *GithubService) CreateIssue(owner string, repo string, title string, body string, laneName string, assignee string, milestoneTitle string) (*github.Issue, error) {
+	request := &github.IssueRequest{
+		Title: &title,
+	}
+	if body != "" {
+		request.Body = &body
+	}
+	if assignee != "" {
+		request.Assignee = &assignee
+	}
+
+	if laneName != "" {
+		lane, err := FindLane(g.lanes(), laneName)
+		if err != nil {
+			return nil, err
+		}
+		if !lane.IsCatchAll() {
+			label, err := g.repoLabelForLane(owner, repo, *lane)
+			if err != nil {
+				return nil, err
+			}
+			request.Labels = &[]string{label}
+		}
+	}
+
+	if milestoneTitle != "" {
+		milestone, err := g.findMilestone(owner, repo, milestoneTitle)
+		if err != nil {
+			return nil, err
+		}
+		request.Milestone = milestone.Number
+	}
+
+	var client = g.obtainAuthenticatedGithubClient()
+	issue, _, err := client.Issues.Create(owner, repo, request)
+	return issue, err
+}
+
+func (g *GithubService) CloseIssue(owner string, repo string, number int) (*github.Issue, error) {
+	return g.setIssueState(owner, repo, number, "closed")
+}
+
+func (g *GithubService) ReopenIssue(owner string, repo string, number int) (*github.Issue, error) {
+	return g.setIssueState(owner, repo, number, "open")
+}
+
+func (g *GithubService) setIssueState(owner string, repo string, number int, state string) (*github.Issue, error) {
+	var client = g.obtainAuthenticatedGithubClient()
+	issue, _, err := client.Issues.Edit(owner, repo, number, &github.IssueRequest{State: &state})
+	return issue, err
+}
+
+func (g *GithubService) CommentOnIssue(owner string, repo string, number int, body string) (*github.Issue, error) {
+	var client = g.obtainAuthenticatedGithubClient()
+	_, _, err := client.Issues.CreateComment(owner, repo, number, &github.IssueComment{Body: &body})
+	if err != nil {
+		return nil, err
+	}
+	issue, _, err := client.Issues.Get(owner, repo, number)
+	return issue, err
+}
+
+func (g *GithubService) IsCollaborator(owner string, repo string, login string) (bool, error) {
+	var client = g.obtainAuthenticatedGithubClient()
+	isCollaborator, _, err := client.Repositories.IsCollaborator(owner, repo, login)
+	return isCollaborator, err
+}
@@ -0,0 +1,234 @@
+package githubservice
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// Lane is a column of the board. An issue is in a lane when its labels contain
+// every one of the lane's keywords. A lane without keywords catches the issues
+// that are in no other lane, which is how the backlog works. SLA is how many days
+// an issue may sit in the lane before it is stale, with 0 meaning no limit. WIP
+// and AssigneeWIP limit how many issues the lane may hold in a repository and for
+// each assignee, again with 0 meaning no limit.
+type Lane struct {
+	Name        string   `json:"name"`
+	Label       string   `json:"label"`
+	Keywords    []string `json:"keywords"`
+	SLA         int      `json:"sla"`
+	WIP         int      `json:"wip"`
+	AssigneeWIP int      `json:"assigneeWip"`
+}
+
+// DefaultLanes are the Waffle lanes Marvin has always known about, in board order.
+var DefaultLanes = []Lane{
+	{Name: "Backlog"},
+	{Name: "Sprint", Label: "sprint", Keywords: []string{"sprint"}},
+	{Name: "In Progress", Label: "in progress", Keywords: []string{"in", "progress"}},
+	{Name: "Ready for Review", Label: "ready for review", Keywords: []string{"ready", "for", "review"}},
+	{Name: "Ready for QA", Label: "ready for qa", Keywords: []string{"ready", "for", "qa"}},
+	{Name: "QA Pass", Label: "qa pass", Keywords: []string{"qa", "pass"}},
+	{Name: "Done", Label: "done", Keywords: []string{"done"}},
+}
+
+// IsCatchAll is true for the lane that holds issues without any lane label.
+func (l Lane) IsCatchAll() bool {
+	return len(l.Keywords) == 0
+}
+
+// MatchesLabel says whether a single label puts an issue in this lane.
+func (l Lane) MatchesLabel(label string) bool {
+	if l.IsCatchAll() {
+		return false
+	}
+	label = strings.ToLower(label)
+	for _, keyword := range l.Keywords {
+		if !strings.Contains(label, strings.ToLower(keyword)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches says whether the issue's labels put it in this lane, ignoring the other lanes.
+func (l Lane) Matches(issue github.Issue) bool {
+	if l.IsCatchAll() {
+		return false
+	}
+	for _, label := range issue.Labels {
+		if l.MatchesLabel(*label.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// LaneForIssue finds the lane an issue is in. When its labels match several lanes
+// the one furthest along the board wins, and when they match none it is in the
+// catch-all lane, if there is one.
+func LaneForIssue(lanes []Lane, issue github.Issue) *Lane {
+	var found *Lane
+	var catchAll *Lane
+	for i := range lanes {
+		if lanes[i].IsCatchAll() {
+			if catchAll == nil {
+				catchAll = &lanes[i]
+			}
+		} else if lanes[i].Matches(issue) {
+			found = &lanes[i]
+		}
+	}
+	if found == nil {
+		return catchAll
+	}
+	return found
+}
+
+// FindLane looks a lane up by name, ignoring case, spaces, dashes and underscores,
+// so "in-progress", "inprogress" and "In Progress" are all the same lane.
+func FindLane(lanes []Lane, name string) (*Lane, error) {
+	wanted := normalizeLaneName(name)
+	for i := range lanes {
+		if normalizeLaneName(lanes[i].Name) == wanted || (lanes[i].Label != "" && normalizeLaneName(lanes[i].Label) == wanted) {
+			return &lanes[i], nil
+		}
+	}
+
+	var names []string
+	for _, lane := range lanes {
+		names = append(names, lane.Name)
+	}
+	return nil, errors.New("there is no lane called " + name + ", try one of: " + strings.Join(names, ", "))
+}
+
+func normalizeLaneName(name string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "", "_", "").Replace(name))
+}
+
+func (g *GithubService) lanes() []Lane {
+	if len(g.Lanes) > 0 {
+		return g.Lanes
+	}
+	return DefaultLanes
+}
+
+// MoveIssue moves an issue to the named lane by removing the labels of every
+// lane it is in and adding the label of the target lane. It returns the lane
+// the issue was in before and the lane it is in now.
+func (g *GithubService) MoveIssue(owner string, repo string, number int, laneName string) (*github.Issue, *Lane, *Lane, error) {
+	lanes := g.lanes()
+	target, err := FindLane(lanes, laneName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var client = g.obtainAuthenticatedGithubClient()
+	issue, _, err := client.Issues.Get(owner, repo, number)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	from := LaneForIssue(lanes, *issue)
+
+	for _, label := range issue.Labels {
+		inLane := false
+		for _, lane := range lanes {
+			if lane.MatchesLabel(*label.Name) {
+				inLane = true
+				break
+			}
+		}
+		if inLane && !(target.MatchesLabel(*label.Name)) {
+			_, err = client.Issues.RemoveLabelForIssue(owner, repo, number, *label.Name)
+			if err != nil {
+				return nil, from, nil, err
+			}
+		}
+	}
+
+	if !target.IsCatchAll() && !target.Matches(*issue) {
+		label, err := g.repoLabelForLane(owner, repo, *target)
+		if err != nil {
+			return nil, from, nil, err
+		}
+		_, _, err = client.Issues.AddLabelsToIssue(owner, repo, number, []string{label})
+		if err != nil {
+			return nil, from, nil, err
+		}
+	}
+
+	issue, _, err = client.Issues.Get(owner, repo, number)
+	if err != nil {
+		return nil, from, nil, err
+	}
+	return issue, from, LaneForIssue(lanes, *issue), nil
+}
+
+// repoLabelForLane finds the repository's own label for a lane so its spelling and
+// colour are kept, falling back to the label from the lane configuration.
+func (g *GithubService) repoLabelForLane(owner string, repo string, lane Lane) (string, error) {
+	var client = g.obtainAuthenticatedGithubClient()
+	opt := &github.ListOptions{PerPage: 100}
+
+	for {
+		labels, resp, err := client.Issues.ListLabels(owner, repo, opt)
+		if err != nil {
+			return "", err
+		}
+
+		for _, label := range labels {
+			if lane.MatchesLabel(*label.Name) {
+				return *label.Name, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	if lane.Label == "" {
+		return "", errors.New("lane " + lane.Name + " has no label")
+	}
+	return lane.Label, nil
+}
+
+// IssuesByLane groups the open issues of repo by lane, loading them the same way the
+// lane commands do. Every lane is in the result, even when it is empty.
+func (g *GithubService) IssuesByLane(owner string, repo string) (map[string][]github.Issue, error) {
+	issues, err := g.makeIssueList(owner, repo, "", g.any)
+	if err != nil {
+		return nil, err
+	}
+
+	lanes := g.lanes()
+	byLane := make(map[string][]github.Issue)
+	for _, lane := range lanes {
+		byLane[lane.Name] = nil
+	}
+	for _, issue := range issues {
+		if issue.PullRequestLinks != nil {
+			continue
+		}
+		if lane := LaneForIssue(lanes, issue); lane != nil {
+			byLane[lane.Name] = append(byLane[lane.Name], issue)
+		}
+	}
+	return byLane, nil
+}
+
+// LaneCounts counts the open issues of repo in each lane.
+func (g *GithubService) LaneCounts(owner string, repo string) (map[string]int, error) {
+	byLane, err := g.IssuesByLane(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for lane, issues := range byLane {
+		counts[lane] = len(issues)
+	}
+	return counts, nil
+}
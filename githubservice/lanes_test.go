@@ -0,0 +1,56 @@
+package githubservice
+
+import (
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/google/go-github/github"
+	. "github.com/onsi/gomega"
+)
+
+func issueWithLabels(names ...string) github.Issue {
+	var labels []github.Label
+	for i := range names {
+		labels = append(labels, github.Label{Name: &names[i]})
+	}
+	return github.Issue{Labels: labels}
+}
+
+func TestLanes(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Lanes", func() {
+		g.It("Should put issues without lane labels in the backlog", func() {
+			lane := LaneForIssue(DefaultLanes, issueWithLabels("bug"))
+
+			Expect(lane.Name).To(Equal("Backlog"))
+		})
+
+		g.It("Should put issues in the lane matching their labels", func() {
+			lane := LaneForIssue(DefaultLanes, issueWithLabels("bug", "Ready for QA"))
+
+			Expect(lane.Name).To(Equal("Ready for QA"))
+		})
+
+		g.It("Should prefer the lane furthest along the board", func() {
+			lane := LaneForIssue(DefaultLanes, issueWithLabels("sprint", "In Progress"))
+
+			Expect(lane.Name).To(Equal("In Progress"))
+		})
+
+		g.It("Should find lanes however their name is written", func() {
+			for _, name := range []string{"inprogress", "in-progress", "In Progress"} {
+				lane, err := FindLane(DefaultLanes, name)
+
+				Expect(err).To(BeNil())
+				Expect(lane.Name).To(Equal("In Progress"))
+			}
+
+			_, err := FindLane(DefaultLanes, "limbo")
+			Expect(err).ToNot(BeNil())
+		})
+	})
+}
@@ -0,0 +1,139 @@
+package githubservice
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// How far back the closing rate of a milestone is measured when projecting when it
+// will be done.
+const closingRateWindow = 14 * 24 * time.Hour
+
+// MilestoneProgress is a milestone with its issues, open and closed.
+type MilestoneProgress struct {
+	Milestone github.Milestone
+	Issues    []github.Issue
+}
+
+// OpenMilestones lists the open milestones of repo.
+func (g *GithubService) OpenMilestones(owner string, repo string) ([]github.Milestone, error) {
+	return g.loadMilestonesForRepo(owner, repo, "open")
+}
+
+// Milestone loads an open milestone by title along with all of its issues.
+func (g *GithubService) Milestone(owner string, repo string, title string) (*MilestoneProgress, error) {
+	milestone, err := g.findMilestone(owner, repo, title)
+	if err != nil {
+		return nil, err
+	}
+
+	issues, err := g.loadIssuesForMilestone(owner, repo, *milestone.Number)
+	if err != nil {
+		return nil, err
+	}
+	return &MilestoneProgress{Milestone: *milestone, Issues: issues}, nil
+}
+
+func (g *GithubService) loadIssuesForMilestone(owner string, repo string, number int) ([]github.Issue, error) {
+	var client = g.obtainAuthenticatedGithubClient()
+	var allIssues []github.Issue
+	var e error
+	opt := &github.IssueListByRepoOptions{
+		Milestone:   strconv.Itoa(number),
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		issues, resp, err := client.Issues.ListByRepo(owner, repo, opt)
+
+		if err != nil {
+			e = err
+			break
+		}
+
+		for _, issue := range issues {
+			if issue.PullRequestLinks == nil {
+				allIssues = append(allIssues, issue)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opt.ListOptions.Page = resp.NextPage
+	}
+
+	return allIssues, e
+}
+
+// OpenIssues lists the issues of the milestone that are still open.
+func (m MilestoneProgress) OpenIssues() []github.Issue {
+	var open []github.Issue
+	for _, issue := range m.Issues {
+		if issue.ClosedAt == nil {
+			open = append(open, issue)
+		}
+	}
+	return open
+}
+
+// LaneCounts counts the open issues of the milestone in each lane.
+func (m MilestoneProgress) LaneCounts(lanes []Lane) map[string]int {
+	counts := make(map[string]int)
+	for _, issue := range m.OpenIssues() {
+		if lane := LaneForIssue(lanes, issue); lane != nil {
+			counts[lane.Name]++
+		}
+	}
+	return counts
+}
+
+// ClosingRate is the number of issues closed per day over the last two weeks.
+func (m MilestoneProgress) ClosingRate(now time.Time) float64 {
+	closed := 0
+	for _, issue := range m.Issues {
+		if issue.ClosedAt != nil && now.Sub(*issue.ClosedAt) <= closingRateWindow {
+			closed++
+		}
+	}
+	return float64(closed) / closingRateWindow.Hours() * 24
+}
+
+// ProjectedCompletion is when the open issues will all be closed if they keep being
+// closed at the recent rate. It is false when nothing was closed recently.
+func (m MilestoneProgress) ProjectedCompletion(now time.Time) (time.Time, bool) {
+	open := len(m.OpenIssues())
+	if open == 0 {
+		return now, true
+	}
+	rate := m.ClosingRate(now)
+	if rate == 0 {
+		return time.Time{}, false
+	}
+	days := math.Ceil(float64(open) / rate)
+	return now.Add(time.Duration(days) * 24 * time.Hour), true
+}
+
+// Burndown counts the issues of the milestone still open at the end of each day from
+// start until end.
+func (m MilestoneProgress) Burndown(start time.Time, end time.Time) (days []time.Time, remaining []float64) {
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		endOfDay := day.AddDate(0, 0, 1)
+		open := 0
+		for _, issue := range m.Issues {
+			created := issue.CreatedAt == nil || issue.CreatedAt.Before(endOfDay)
+			closed := issue.ClosedAt != nil && issue.ClosedAt.Before(endOfDay)
+			if created && !closed {
+				open++
+			}
+		}
+		days = append(days, day)
+		remaining = append(remaining, float64(open))
+	}
+	return days, remaining
+}
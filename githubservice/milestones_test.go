@@ -0,0 +1,65 @@
+package githubservice
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+	"github.com/google/go-github/github"
+	. "github.com/onsi/gomega"
+)
+
+func milestoneIssue(created time.Time, closed *time.Time, labels ...string) github.Issue {
+	issue := issueWithLabels(labels...)
+	issue.CreatedAt = &created
+	issue.ClosedAt = closed
+	return issue
+}
+
+func TestMilestones(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	start := time.Date(2016, time.March, 1, 0, 0, 0, 0, time.UTC)
+	now := start.AddDate(0, 0, 14)
+	closedOn := func(day int) *time.Time {
+		t := start.AddDate(0, 0, day).Add(12 * time.Hour)
+		return &t
+	}
+
+	progress := MilestoneProgress{Issues: []github.Issue{
+		milestoneIssue(start, closedOn(2)),
+		milestoneIssue(start, closedOn(5)),
+		milestoneIssue(start, nil, "in progress"),
+		milestoneIssue(start, nil),
+		milestoneIssue(start.AddDate(0, 0, 3), nil),
+	}}
+
+	g.Describe("Milestone progress", func() {
+		g.It("Should count open issues by lane", func() {
+			Expect(progress.LaneCounts(DefaultLanes)).To(Equal(map[string]int{"Backlog": 2, "In Progress": 1}))
+		})
+
+		g.It("Should project completion from the recent closing rate", func() {
+			done, ok := progress.ProjectedCompletion(now)
+
+			// Two issues in two weeks is one a week, so three open issues take three weeks.
+			Expect(ok).To(BeTrue())
+			Expect(done).To(Equal(now.AddDate(0, 0, 21)))
+		})
+
+		g.It("Should not project completion when nothing is being closed", func() {
+			_, ok := progress.ProjectedCompletion(now.AddDate(0, 1, 0))
+
+			Expect(ok).To(BeFalse())
+		})
+
+		g.It("Should count the issues left at the end of each day", func() {
+			_, remaining := progress.Burndown(start, start.AddDate(0, 0, 5))
+
+			Expect(remaining).To(Equal([]float64{4, 4, 3, 4, 4, 3}))
+		})
+	})
+}
@@ -0,0 +1,197 @@
+package githubservice
+
+import (
+	"errors"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// PullRequestFilter narrows down the open pull requests of an organization.
+//
+// A repository is included when its name matches one of Repos, which may use
+// wildcards like "ios-*", or when it has one of Topics. With neither every active
+// repository is included. Pull requests then have to carry one of Labels, be opened
+// by one of Authors or a member of one of Teams, and target Base, for each of these
+// that is set.
+type PullRequestFilter struct {
+	Repos         []string
+	Topics        []string
+	Labels        []string
+	Authors       []string
+	Teams         []string
+	Base          string
+	ExcludeDrafts bool
+}
+
+// IsEmpty is true when the filter lets every pull request through.
+func (f PullRequestFilter) IsEmpty() bool {
+	return len(f.Repos) == 0 && len(f.Topics) == 0 && len(f.Labels) == 0 && len(f.Authors) == 0 &&
+		len(f.Teams) == 0 && f.Base == "" && !f.ExcludeDrafts
+}
+
+// MatchesRepo checks a repository's name and topics against Repos and Topics.
+func (f PullRequestFilter) MatchesRepo(name string, topics []string) bool {
+	if len(f.Repos) == 0 && len(f.Topics) == 0 {
+		return true
+	}
+	for _, pattern := range f.Repos {
+		if matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(name)); matched {
+			return true
+		}
+	}
+	for _, topic := range topics {
+		if containsFold(f.Topics, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesPullRequest checks a pull request against Labels, Authors, Base and
+// ExcludeDrafts. Teams have to be turned into Authors first.
+func (f PullRequestFilter) MatchesPullRequest(pullRequest github.PullRequest, labels []string, draft bool) bool {
+	if f.ExcludeDrafts && draft {
+		return false
+	}
+	if f.Base != "" && (pullRequest.Base == nil || pullRequest.Base.Ref == nil || !strings.EqualFold(*pullRequest.Base.Ref, f.Base)) {
+		return false
+	}
+	if len(f.Authors) > 0 && (pullRequest.User == nil || pullRequest.User.Login == nil || !containsFold(f.Authors, *pullRequest.User.Login)) {
+		return false
+	}
+	if len(f.Labels) > 0 {
+		for _, label := range labels {
+			if containsFold(f.Labels, label) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// needsTopics is true when repositories have to be matched by topic, which takes
+// a request for each repository.
+func (f PullRequestFilter) needsTopics() bool {
+	return len(f.Topics) > 0
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// listedPullRequest is a pull request as the list endpoint returns it, with the
+// draft flag and labels go-github doesn't know about yet.
+type listedPullRequest struct {
+	github.PullRequest
+	Draft  *bool          `json:"draft,omitempty"`
+	Labels []github.Label `json:"labels,omitempty"`
+}
+
+func (p listedPullRequest) labelNames() []string {
+	var names []string
+	for _, label := range p.Labels {
+		if label.Name != nil {
+			names = append(names, *label.Name)
+		}
+	}
+	return names
+}
+
+func (g *GithubService) loadListedPRsForRepo(owner string, repo string) ([]listedPullRequest, error) {
+	var client = g.obtainAuthenticatedGithubClient()
+	var allPRs []listedPullRequest
+	page := 1
+
+	for {
+		req, err := client.NewRequest("GET", "repos/"+owner+"/"+repo+"/pulls?state=open&per_page=100&page="+strconv.Itoa(page), nil)
+		if err != nil {
+			return allPRs, err
+		}
+		// Draft pull requests are still behind a preview.
+		req.Header.Set("Accept", "application/vnd.github.shadow-cat-preview+json")
+
+		var pullRequests []listedPullRequest
+		resp, err := client.Do(req, &pullRequests)
+		if err != nil {
+			return allPRs, err
+		}
+		allPRs = append(allPRs, pullRequests...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		page = resp.NextPage
+	}
+
+	return allPRs, nil
+}
+
+type repositoryTopics struct {
+	Names []string `json:"names"`
+}
+
+func (g *GithubService) loadTopicsForRepo(owner string, repo string) ([]string, error) {
+	var client = g.obtainAuthenticatedGithubClient()
+
+	req, err := client.NewRequest("GET", "repos/"+owner+"/"+repo+"/topics", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.mercy-preview+json")
+
+	var topics repositoryTopics
+	_, err = client.Do(req, &topics)
+	return topics.Names, err
+}
+
+// TeamMembers lists the logins of the members of a team, found by its slug.
+func (g *GithubService) TeamMembers(owner string, slug string) ([]string, error) {
+	var client = g.obtainAuthenticatedGithubClient()
+
+	var team *github.Team
+	opt := &github.ListOptions{PerPage: 100}
+	for team == nil {
+		teams, resp, err := client.Organizations.ListTeams(owner, opt)
+		if err != nil {
+			return nil, err
+		}
+		for i := range teams {
+			if teams[i].Slug != nil && strings.EqualFold(*teams[i].Slug, strings.TrimPrefix(slug, owner+"/")) {
+				team = &teams[i]
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	if team == nil {
+		return nil, errors.New("There is no team " + slug + " in " + owner)
+	}
+
+	var logins []string
+	memberOpt := &github.OrganizationListTeamMembersOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		members, resp, err := client.Organizations.ListTeamMembers(*team.ID, memberOpt)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			logins = append(logins, *member.Login)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		memberOpt.Page = resp.NextPage
+	}
+	return logins, nil
+}
@@ -0,0 +1,41 @@
+package githubservice
+
+import (
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/google/go-github/github"
+	. "github.com/onsi/gomega"
+)
+
+func pullRequestBy(login string, base string) github.PullRequest {
+	return github.PullRequest{User: &github.User{Login: &login}, Base: &github.PullRequestBranch{Ref: &base}}
+}
+
+func TestPullRequestFilter(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Pull request filter", func() {
+		g.It("Should match repositories by name pattern or topic", func() {
+			filter := PullRequestFilter{Repos: []string{"ios-*"}, Topics: []string{"Backend"}}
+
+			Expect(filter.MatchesRepo("iOS-app", nil)).To(BeTrue())
+			Expect(filter.MatchesRepo("api", []string{"backend"})).To(BeTrue())
+			Expect(filter.MatchesRepo("android-app", []string{"mobile"})).To(BeFalse())
+			Expect(PullRequestFilter{}.MatchesRepo("anything", nil)).To(BeTrue())
+		})
+
+		g.It("Should match pull requests by label, author, base branch and draft", func() {
+			filter := PullRequestFilter{Labels: []string{"bug"}, Authors: []string{"alice"}, Base: "main", ExcludeDrafts: true}
+
+			Expect(filter.MatchesPullRequest(pullRequestBy("Alice", "main"), []string{"Bug"}, false)).To(BeTrue())
+			Expect(filter.MatchesPullRequest(pullRequestBy("alice", "main"), []string{"bug"}, true)).To(BeFalse())
+			Expect(filter.MatchesPullRequest(pullRequestBy("bob", "main"), []string{"bug"}, false)).To(BeFalse())
+			Expect(filter.MatchesPullRequest(pullRequestBy("alice", "develop"), []string{"bug"}, false)).To(BeFalse())
+			Expect(filter.MatchesPullRequest(pullRequestBy("alice", "main"), []string{"feature"}, false)).To(BeFalse())
+		})
+	})
+}
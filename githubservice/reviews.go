@@ -0,0 +1,392 @@
+package githubservice
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// Review is a review submitted on a pull request. go-github has no reviews API yet
+// so the reviewer endpoints are called by hand.
+type Review struct {
+	User        *github.User `json:"user,omitempty"`
+	State       *string      `json:"state,omitempty"`
+	SubmittedAt *time.Time   `json:"submitted_at,omitempty"`
+}
+
+// The review states GitHub reports.
+const (
+	ReviewApproved         = "APPROVED"
+	ReviewChangesRequested = "CHANGES_REQUESTED"
+	ReviewCommented        = "COMMENTED"
+	ReviewDismissed        = "DISMISSED"
+)
+
+type requestedReviewers struct {
+	Users []github.User `json:"users"`
+	Teams []struct {
+		Slug *string `json:"slug"`
+	} `json:"teams"`
+}
+
+type mergeability struct {
+	Mergeable      *bool   `json:"mergeable"`
+	MergeableState *string `json:"mergeable_state"`
+}
+
+// PullRequestStatus is a pull request with what it is waiting on: reviewers who
+// were asked and haven't answered, the latest review of each reviewer, the combined
+// state of its commit statuses and whether it can be merged. Err says why some of
+// that couldn't be loaded.
+type PullRequestStatus struct {
+	PullRequest        github.PullRequest
+	RequestedReviewers []string
+	Reviews            map[string]string
+	CheckState         string
+	Mergeable          *bool
+	MergeableState     string
+	Err                error
+}
+
+// Approved is true when someone approved and nobody still wants changes.
+func (s PullRequestStatus) Approved() bool {
+	approved := false
+	for _, state := range s.Reviews {
+		if state == ReviewChangesRequested {
+			return false
+		}
+		approved = approved || state == ReviewApproved
+	}
+	return approved
+}
+
+func (s PullRequestStatus) ChangesRequested() bool {
+	for _, state := range s.Reviews {
+		if state == ReviewChangesRequested {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsReview is true while the pull request waits on reviewers rather than its
+// author: someone was asked to review and hasn't yet, or it has no approval and
+// nobody asked for changes.
+func (s PullRequestStatus) NeedsReview() bool {
+	if len(s.RequestedReviewers) > 0 {
+		return true
+	}
+	return !s.Approved() && !s.ChangesRequested()
+}
+
+// Failing is true when one of the commit statuses of the head commit failed.
+func (s PullRequestStatus) Failing() bool {
+	return s.CheckState == "failure" || s.CheckState == "error"
+}
+
+// HasConflicts is true when GitHub can't merge the pull request cleanly.
+func (s PullRequestStatus) HasConflicts() bool {
+	return (s.Mergeable != nil && !*s.Mergeable) || s.MergeableState == "dirty"
+}
+
+// ReviewersWithState lists the logins whose latest review has the given state.
+func (s PullRequestStatus) ReviewersWithState(state string) []string {
+	var logins []string
+	for login, reviewState := range s.Reviews {
+		if reviewState == state {
+			logins = append(logins, login)
+		}
+	}
+	sort.Strings(logins)
+	return logins
+}
+
+// LatestReviewStates finds each reviewer's current verdict from their reviews in the
+// order they were submitted. Comments don't replace an earlier approval or request
+// for changes, and a dismissed review no longer counts.
+func LatestReviewStates(reviews []Review) map[string]string {
+	states := make(map[string]string)
+	for _, review := range reviews {
+		if review.User == nil || review.User.Login == nil || review.State == nil {
+			continue
+		}
+		login := *review.User.Login
+		switch *review.State {
+		case ReviewApproved, ReviewChangesRequested:
+			states[login] = *review.State
+		case ReviewDismissed:
+			delete(states, login)
+		case ReviewCommented:
+			if _, ok := states[login]; !ok {
+				states[login] = ReviewCommented
+			}
+		}
+	}
+	return states
+}
+
+// FilterPullRequestStatuses keeps the pull requests waiting on review when needsReview
+// is set and those with failing statuses when failing is set. With both set a pull
+// request has to match both. Pull requests whose status couldn't be loaded are kept,
+// so the error shows instead of the pull request quietly going missing.
+func FilterPullRequestStatuses(statuses []PullRequestStatus, needsReview bool, failing bool) []PullRequestStatus {
+	var filtered []PullRequestStatus
+	for _, status := range statuses {
+		if status.Err == nil {
+			if needsReview && !status.NeedsReview() {
+				continue
+			}
+			if failing && !status.Failing() {
+				continue
+			}
+		}
+		filtered = append(filtered, status)
+	}
+	return filtered
+}
+
+// PullRequestStatuses loads the review, commit status and mergeability of each pull
+// request and keeps those waiting on review or failing when asked to. Each filter
+// loads only what it needs first, so the rest is only loaded for the pull requests
+// that are shown. A pull request whose status can't be loaded is kept with Err set.
+func (g *GithubService) PullRequestStatuses(pullRequests []github.PullRequest, needsReview bool, failing bool) []PullRequestStatus {
+	var statuses []PullRequestStatus
+	for _, pullRequest := range pullRequests {
+		statuses = append(statuses, PullRequestStatus{PullRequest: pullRequest})
+	}
+
+	if failing {
+		g.loadStatuses(statuses, g.loadChecks)
+		statuses = FilterPullRequestStatuses(statuses, false, true)
+	}
+	if needsReview {
+		g.loadStatuses(statuses, g.loadReviews)
+		statuses = FilterPullRequestStatuses(statuses, true, false)
+	}
+	if !needsReview {
+		g.loadStatuses(statuses, g.loadReviews)
+	}
+	if !failing {
+		g.loadStatuses(statuses, g.loadChecks)
+	}
+	return statuses
+}
+
+// loadStatuses loads part of each status, skipping those that already failed.
+func (g *GithubService) loadStatuses(statuses []PullRequestStatus, load func(*PullRequestStatus) error) {
+	for i := range statuses {
+		if statuses[i].Err != nil {
+			continue
+		}
+		if err := load(&statuses[i]); err != nil {
+			log.Printf("ERROR: Couldn't load the status of %s: %s", *statuses[i].PullRequest.HTMLURL, err)
+			statuses[i].Err = err
+		}
+	}
+}
+
+// loadReviews loads the requested reviewers and the reviews of a pull request.
+func (g *GithubService) loadReviews(status *PullRequestStatus) error {
+	var client = g.obtainAuthenticatedGithubClient()
+	owner, repo := pullRequestRepo(status.PullRequest)
+	path := "repos/" + owner + "/" + repo + "/pulls/" + strconv.Itoa(*status.PullRequest.Number)
+
+	req, err := client.NewRequest("GET", path+"/requested_reviewers", nil)
+	if err != nil {
+		return err
+	}
+	var requested requestedReviewers
+	_, err = client.Do(req, &requested)
+	if err != nil {
+		return err
+	}
+	for _, user := range requested.Users {
+		status.RequestedReviewers = append(status.RequestedReviewers, *user.Login)
+	}
+	for _, team := range requested.Teams {
+		if team.Slug != nil {
+			status.RequestedReviewers = append(status.RequestedReviewers, owner+"/"+*team.Slug)
+		}
+	}
+
+	req, err = client.NewRequest("GET", path+"/reviews?per_page=100", nil)
+	if err != nil {
+		return err
+	}
+	var reviews []Review
+	_, err = client.Do(req, &reviews)
+	if err != nil {
+		return err
+	}
+	status.Reviews = LatestReviewStates(reviews)
+	return nil
+}
+
+// loadChecks loads the combined commit status of the head commit of a pull request
+// and whether it can be merged.
+func (g *GithubService) loadChecks(status *PullRequestStatus) error {
+	var client = g.obtainAuthenticatedGithubClient()
+	pullRequest := status.PullRequest
+	owner, repo := pullRequestRepo(pullRequest)
+	path := "repos/" + owner + "/" + repo + "/pulls/" + strconv.Itoa(*pullRequest.Number)
+
+	if pullRequest.Head != nil && pullRequest.Head.SHA != nil {
+		combined, _, err := client.Repositories.GetCombinedStatus(owner, repo, *pullRequest.Head.SHA, nil)
+		if err != nil {
+			return err
+		}
+		// A commit without any statuses is reported as pending.
+		if combined.State != nil && combined.TotalCount != nil && *combined.TotalCount > 0 {
+			status.CheckState = *combined.State
+		}
+	}
+
+	// Pull request lists leave out mergeability, only the single pull request has it.
+	req, err := client.NewRequest("GET", path, nil)
+	if err != nil {
+		return err
+	}
+	var merge mergeability
+	_, err = client.Do(req, &merge)
+	if err != nil {
+		return err
+	}
+	status.Mergeable = merge.Mergeable
+	if merge.MergeableState != nil {
+		status.MergeableState = *merge.MergeableState
+	}
+	return nil
+}
+
+// pullRequestRepo finds the owner and name of the repository a pull request is in.
+func pullRequestRepo(pullRequest github.PullRequest) (owner string, repo string) {
+	if pullRequest.Base != nil && pullRequest.Base.Repo != nil && pullRequest.Base.Repo.FullName != nil {
+		parts := strings.SplitN(*pullRequest.Base.Repo.FullName, "/", 2)
+		if len(parts) == 2 {
+			return parts[0], parts[1]
+		}
+	}
+	// https://github.com/owner/repo/pull/12
+	parts := strings.Split(*pullRequest.HTMLURL, "/")
+	return parts[len(parts)-4], parts[len(parts)-3]
+}
+
+// ReviewRequest is an open pull request waiting on someone, with the reason why.
+type ReviewRequest struct {
+	PullRequest github.Issue
+	Reason      string
+}
+
+// Why a pull request is in someone's review queue.
+const (
+	ReasonReviewRequested = "review requested"
+	ReasonMentioned       = "mentioned"
+)
+
+// GitHub allows 30 searches a minute, so searches are spaced out to stay under it.
+const searchInterval = 2 * time.Second
+
+var searchMutex sync.Mutex
+var lastSearch time.Time
+
+// waitToSearch blocks until another search fits in the rate limit.
+func waitToSearch() {
+	searchMutex.Lock()
+	defer searchMutex.Unlock()
+	if wait := searchInterval - time.Since(lastSearch); wait > 0 {
+		time.Sleep(wait)
+	}
+	lastSearch = time.Now()
+}
+
+func (g *GithubService) searchIssues(query string) ([]github.Issue, error) {
+	var client = g.obtainAuthenticatedGithubClient()
+	var all []github.Issue
+	var e error
+	opt := &github.SearchOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		waitToSearch()
+		issueSearchResults, resp, err := client.Search.Issues(query, opt)
+
+		if err != nil {
+			e = err
+			break
+		}
+
+		all = append(all, issueSearchResults.Issues...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opt.ListOptions.Page = resp.NextPage
+	}
+
+	return all, e
+}
+
+// ReviewQueue finds the open pull requests across the organization that wait on
+// login: those where they are a requested reviewer, and those that mention them
+// where they haven't reviewed or commented yet. The oldest come first.
+func (g *GithubService) ReviewQueue(owner string, login string) ([]ReviewRequest, error) {
+	requested, err := g.searchIssues(reviewRequestedQuery(owner, login))
+	if err != nil {
+		return nil, err
+	}
+	mentioned, err := g.searchIssues(mentionedQuery(owner, login))
+	if err != nil {
+		return nil, err
+	}
+	return mergeReviewQueue(requested, mentioned), nil
+}
+
+func reviewRequestedQuery(owner string, login string) string {
+	return "is:open is:pr user:" + owner + " review-requested:" + login
+}
+
+// mentionedQuery leaves out the pull requests login wrote, reviewed or commented on,
+// as those no longer wait on them.
+func mentionedQuery(owner string, login string) string {
+	return "is:open is:pr user:" + owner + " mentions:" + login + " -author:" + login + " -reviewed-by:" + login + " -commenter:" + login
+}
+
+// mergeReviewQueue lists each pull request once, as requested when it is both
+// requested and mentioned, oldest first.
+func mergeReviewQueue(requested []github.Issue, mentioned []github.Issue) []ReviewRequest {
+	var queue []ReviewRequest
+	seen := make(map[string]bool)
+	for _, pullRequest := range requested {
+		seen[*pullRequest.HTMLURL] = true
+		queue = append(queue, ReviewRequest{PullRequest: pullRequest, Reason: ReasonReviewRequested})
+	}
+	for _, pullRequest := range mentioned {
+		if !seen[*pullRequest.HTMLURL] {
+			seen[*pullRequest.HTMLURL] = true
+			queue = append(queue, ReviewRequest{PullRequest: pullRequest, Reason: ReasonMentioned})
+		}
+	}
+	sort.Sort(reviewRequestsByAge(queue))
+	return queue
+}
+
+type reviewRequestsByAge []ReviewRequest
+
+func (r reviewRequestsByAge) Len() int      { return len(r) }
+func (r reviewRequestsByAge) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r reviewRequestsByAge) Less(i, j int) bool {
+	return r[i].PullRequest.CreatedAt.Before(*r[j].PullRequest.CreatedAt)
+}
+
+// ChangesRequestedOf finds the open pull requests by login across the organization
+// where a reviewer asked for changes.
+func (g *GithubService) ChangesRequestedOf(owner string, login string) ([]github.Issue, error) {
+	return g.searchIssues("is:open is:pr user:" + owner + " author:" + login + " review:changes_requested")
+}
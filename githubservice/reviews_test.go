@@ -0,0 +1,84 @@
+package githubservice
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+	"github.com/google/go-github/github"
+	. "github.com/onsi/gomega"
+)
+
+func review(login string, state string) Review {
+	return Review{User: &github.User{Login: &login}, State: &state}
+}
+
+func pullRequestIssue(number string, daysAgo int) github.Issue {
+	url := "https://github.com/RobotsAndPencils/marvin/pull/" + number
+	created := time.Date(2016, time.March, 29, 12, 0, 0, 0, time.UTC).AddDate(0, 0, -daysAgo)
+	return github.Issue{HTMLURL: &url, CreatedAt: &created}
+}
+
+func TestReviews(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Reviews", func() {
+		g.It("Should keep each reviewer's latest verdict", func() {
+			states := LatestReviewStates([]Review{
+				review("alice", ReviewChangesRequested),
+				review("alice", ReviewCommented),
+				review("bob", ReviewCommented),
+				review("carol", ReviewApproved),
+				review("carol", ReviewDismissed),
+				review("alice", ReviewApproved),
+			})
+
+			Expect(states).To(Equal(map[string]string{"alice": ReviewApproved, "bob": ReviewCommented}))
+		})
+
+		g.It("Should filter pull requests waiting on review or failing", func() {
+			waiting := PullRequestStatus{Reviews: map[string]string{"bob": ReviewCommented}}
+			failing := PullRequestStatus{Reviews: map[string]string{"bob": ReviewApproved}, CheckState: "failure"}
+			changes := PullRequestStatus{Reviews: map[string]string{"bob": ReviewChangesRequested}, CheckState: "error"}
+			statuses := []PullRequestStatus{waiting, failing, changes}
+
+			Expect(FilterPullRequestStatuses(statuses, true, false)).To(Equal([]PullRequestStatus{waiting}))
+			Expect(FilterPullRequestStatuses(statuses, false, true)).To(Equal([]PullRequestStatus{failing, changes}))
+			Expect(FilterPullRequestStatuses(statuses, true, true)).To(BeEmpty())
+		})
+
+		g.It("Should search for requested reviews and for mentions not yet answered", func() {
+			Expect(reviewRequestedQuery("RobotsAndPencils", "bob")).To(Equal("is:open is:pr user:RobotsAndPencils review-requested:bob"))
+			Expect(mentionedQuery("RobotsAndPencils", "bob")).To(Equal("is:open is:pr user:RobotsAndPencils mentions:bob -author:bob -reviewed-by:bob -commenter:bob"))
+		})
+
+		g.It("Should list each pull request in the review queue once, oldest first", func() {
+			queue := mergeReviewQueue(
+				[]github.Issue{pullRequestIssue("1", 2), pullRequestIssue("2", 5)},
+				[]github.Issue{pullRequestIssue("2", 5), pullRequestIssue("3", 9), pullRequestIssue("3", 9)},
+			)
+
+			Expect(queue).To(HaveLen(3))
+			Expect(*queue[0].PullRequest.HTMLURL).To(HaveSuffix("/3"))
+			Expect(queue[0].Reason).To(Equal(ReasonMentioned))
+			Expect(*queue[1].PullRequest.HTMLURL).To(HaveSuffix("/2"))
+			Expect(queue[1].Reason).To(Equal(ReasonReviewRequested))
+			Expect(*queue[2].PullRequest.HTMLURL).To(HaveSuffix("/1"))
+			Expect(queue[2].Reason).To(Equal(ReasonReviewRequested))
+		})
+
+		g.It("Should need review again once the author asks for it after changes were requested", func() {
+			status := PullRequestStatus{
+				Reviews:            map[string]string{"alice": ReviewChangesRequested},
+				RequestedReviewers: []string{"bob"},
+			}
+
+			Expect(status.ChangesRequested()).To(BeTrue())
+			Expect(status.NeedsReview()).To(BeTrue())
+			Expect(FilterPullRequestStatuses([]PullRequestStatus{status}, true, false)).To(HaveLen(1))
+		})
+	})
+}
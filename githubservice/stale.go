@@ -0,0 +1,102 @@
+package githubservice
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// StaleItem is an open issue or pull request that has sat still for too long.
+// Lane is empty for pull requests. IdleDays counts since anything last happened
+// to it and LaneDays since it entered its lane. BreachedSLA is true when it has
+// been in its lane longer than the lane allows.
+type StaleItem struct {
+	Issue       github.Issue
+	Lane        string
+	IdleDays    float64
+	LaneDays    float64
+	SLA         int
+	BreachedSLA bool
+}
+
+// IsPullRequest is true when the stale item is a pull request rather than an issue.
+func (s StaleItem) IsPullRequest() bool {
+	return s.Issue.PullRequestLinks != nil
+}
+
+// Staleness decides whether an item is stale. Its lane's SLA counts the time in
+// the lane, and staleDays, when more than 0, counts the time without any activity.
+func Staleness(idleDays float64, laneDays float64, sla int, staleDays int) (stale bool, breachedSLA bool) {
+	breachedSLA = sla > 0 && laneDays > float64(sla)
+	return breachedSLA || (staleDays > 0 && idleDays > float64(staleDays)), breachedSLA
+}
+
+// StaleWork finds the open issues of repo that have been in their lane longer than
+// its SLA or had no activity for staleDays, and the pull requests idle for staleDays.
+// The longest idle come first.
+func (g *GithubService) StaleWork(owner string, repo string, staleDays int, now time.Time) ([]StaleItem, error) {
+	issues, err := g.loadIssuesForRepo(owner, repo, "")
+	if err != nil {
+		return nil, err
+	}
+
+	lanes := g.lanes()
+	var stale []StaleItem
+	for _, issue := range issues {
+		item := StaleItem{Issue: issue, IdleDays: g.daysBetween(*issue.UpdatedAt, now)}
+		item.LaneDays = item.IdleDays
+
+		if !item.IsPullRequest() {
+			if lane := LaneForIssue(lanes, issue); lane != nil {
+				item.Lane = lane.Name
+				item.SLA = lane.SLA
+			}
+
+			// Only the events tell how long it has been in its lane, and they are only
+			// worth loading when the lane has an SLA the idle time doesn't already break.
+			if item.SLA > 0 && item.IdleDays <= float64(item.SLA) {
+				events, err := g.loadEventsForIssue(owner, repo, *issue.Number)
+				if err != nil {
+					return nil, err
+				}
+				intervals := ReplayLaneHistory(lanes, issue, events, now)
+				if len(intervals) > 0 {
+					item.LaneDays = g.daysBetween(intervals[len(intervals)-1].Start, now)
+				}
+			}
+		}
+
+		var isStale bool
+		isStale, item.BreachedSLA = Staleness(item.IdleDays, item.LaneDays, item.SLA, staleDays)
+		if isStale {
+			stale = append(stale, item)
+		}
+	}
+
+	sort.Sort(staleItemsByIdle(stale))
+	return stale, nil
+}
+
+// daysBetween counts the days from start to end, in business days when the
+// service has a calendar.
+func (g *GithubService) daysBetween(start time.Time, end time.Time) float64 {
+	if g.Calendar == nil {
+		return end.Sub(start).Hours() / 24
+	}
+	return g.Calendar.BusinessDays(start, end)
+}
+
+// daysAgo goes back days from now, in business days when the service has a calendar.
+func (g *GithubService) daysAgo(days int) time.Time {
+	if g.Calendar == nil {
+		return time.Now().AddDate(0, 0, -days)
+	}
+	return g.Calendar.SubtractBusinessDays(time.Now(), days)
+}
+
+type staleItemsByIdle []StaleItem
+
+func (s staleItemsByIdle) Len() int           { return len(s) }
+func (s staleItemsByIdle) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s staleItemsByIdle) Less(i, j int) bool { return s[i].IdleDays > s[j].IdleDays }
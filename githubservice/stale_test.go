@@ -0,0 +1,39 @@
+package githubservice
+
+import (
+	"testing"
+
+	. "github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestStale(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Staleness", func() {
+		g.It("Should flag items in their lane longer than its SLA", func() {
+			stale, breached := Staleness(1, 3, 2, 14)
+
+			Expect(stale).To(BeTrue())
+			Expect(breached).To(BeTrue())
+		})
+
+		g.It("Should flag items without activity for too long", func() {
+			stale, breached := Staleness(15, 15, 0, 14)
+
+			Expect(stale).To(BeTrue())
+			Expect(breached).To(BeFalse())
+		})
+
+		g.It("Should leave recent items and lanes without limits alone", func() {
+			stale, _ := Staleness(1, 1.5, 2, 14)
+			Expect(stale).To(BeFalse())
+
+			stale, _ = Staleness(30, 30, 0, 0)
+			Expect(stale).To(BeFalse())
+		})
+	})
+}
@@ -0,0 +1,148 @@
+package githubservice
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// Iteration is a sprint, either a sprint milestone or a fixed-length window of time,
+// with the issues closed in it.
+type Iteration struct {
+	Name       string
+	Start      time.Time
+	End        time.Time
+	InProgress bool
+	Issues     []github.Issue
+}
+
+// Throughput is the number of issues closed in the iteration.
+func (i Iteration) Throughput() int {
+	return len(i.Issues)
+}
+
+// Velocity adds up the points of the closed issues that have an estimate and says
+// how many of them did.
+func (i Iteration) Velocity(e *Estimator) (points float64, estimated int) {
+	for _, issue := range i.Issues {
+		if p, ok := e.Estimate(issue); ok {
+			points += p
+			estimated++
+		}
+	}
+	return points, estimated
+}
+
+// Iterations finds the last count sprints of repo. Milestones named like sprints are
+// used when the repository has any; otherwise the issues closed in the last count
+// windows of length are grouped, ending now.
+func (g *GithubService) Iterations(owner string, repo string, count int, length time.Duration, now time.Time) ([]Iteration, bool, error) {
+	milestones, err := g.loadMilestonesForRepo(owner, repo, "all")
+	if err != nil {
+		return nil, false, err
+	}
+
+	sprints := SprintMilestones(milestones, count, now)
+	if len(sprints) == 0 {
+		since := now.Add(-time.Duration(count) * length)
+		issues, err := g.loadIssuesUpdatedSince(owner, repo, since)
+		if err != nil {
+			return nil, false, err
+		}
+		return WindowIterations(issues, count, length, now), false, nil
+	}
+
+	var iterations []Iteration
+	for _, milestone := range sprints {
+		issues, err := g.loadIssuesForMilestone(owner, repo, *milestone.Number)
+		if err != nil {
+			return nil, true, err
+		}
+
+		iteration := Iteration{
+			Name:       *milestone.Title,
+			InProgress: milestone.State != nil && *milestone.State == "open",
+		}
+		if milestone.CreatedAt != nil {
+			iteration.Start = *milestone.CreatedAt
+		}
+		iteration.End = milestoneEnd(milestone)
+		for _, issue := range issues {
+			if issue.ClosedAt != nil {
+				iteration.Issues = append(iteration.Issues, issue)
+			}
+		}
+		iterations = append(iterations, iteration)
+	}
+	return iterations, true, nil
+}
+
+// SprintMilestones picks the last count milestones named like sprints, oldest first,
+// leaving out sprints that are due to end after the current one.
+func SprintMilestones(milestones []github.Milestone, count int, now time.Time) []github.Milestone {
+	var sprints []github.Milestone
+	for _, milestone := range milestones {
+		if milestone.Title != nil && isSprintName(*milestone.Title) {
+			sprints = append(sprints, milestone)
+		}
+	}
+	sort.Sort(milestonesByEnd(sprints))
+
+	// Everything up to and including the first sprint that hasn't ended yet.
+	last := len(sprints)
+	for i, sprint := range sprints {
+		if milestoneEnd(sprint).After(now) {
+			last = i + 1
+			break
+		}
+	}
+	sprints = sprints[:last]
+
+	if len(sprints) > count {
+		sprints = sprints[len(sprints)-count:]
+	}
+	return sprints
+}
+
+// milestoneEnd is when a milestone is due, or when it was closed if it has no due date.
+func milestoneEnd(milestone github.Milestone) time.Time {
+	if milestone.DueOn != nil {
+		return *milestone.DueOn
+	} else if milestone.ClosedAt != nil {
+		return *milestone.ClosedAt
+	}
+	// Open milestones without a due date are the sprint in progress.
+	return farFuture
+}
+
+var farFuture = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+type milestonesByEnd []github.Milestone
+
+func (m milestonesByEnd) Len() int           { return len(m) }
+func (m milestonesByEnd) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+func (m milestonesByEnd) Less(i, j int) bool { return milestoneEnd(m[i]).Before(milestoneEnd(m[j])) }
+
+// WindowIterations groups closed issues into count windows of length, the last one
+// ending now and so still in progress, oldest first.
+func WindowIterations(issues []github.Issue, count int, length time.Duration, now time.Time) []Iteration {
+	iterations := make([]Iteration, count)
+	for i := range iterations {
+		end := now.Add(-time.Duration(count-1-i) * length)
+		iterations[i] = Iteration{Start: end.Add(-length), End: end, InProgress: i == count-1}
+	}
+
+	for _, issue := range issues {
+		if issue.ClosedAt == nil {
+			continue
+		}
+		for i := range iterations {
+			if issue.ClosedAt.After(iterations[i].Start) && !issue.ClosedAt.After(iterations[i].End) {
+				iterations[i].Issues = append(iterations[i].Issues, issue)
+				break
+			}
+		}
+	}
+	return iterations
+}
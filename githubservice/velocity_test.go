@@ -0,0 +1,127 @@
+package githubservice
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+	"github.com/google/go-github/github"
+	. "github.com/onsi/gomega"
+)
+
+func TestVelocity(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	now := time.Date(2016, time.March, 29, 12, 0, 0, 0, time.UTC)
+	twoWeeks := 14 * 24 * time.Hour
+
+	g.Describe("Estimates", func() {
+		g.It("Should read points from estimate labels", func() {
+			for _, label := range []string{"points: 3", "Points 3", "3 pts", "3 points", "sp 3"} {
+				points, ok := ParseEstimate(issueWithLabels("bug", label))
+				Expect(ok).To(BeTrue())
+				Expect(points).To(Equal(3.0))
+			}
+		})
+
+		g.It("Should read configured sizes and front matter along with the default patterns", func() {
+			estimator, err := NewEstimator(EstimateConfig{
+				Sizes:       map[string]float64{"size/S": 1, "size/M": 3},
+				FrontMatter: []string{"points"},
+			})
+			Expect(err).To(BeNil())
+
+			points, ok := estimator.Estimate(issueWithLabels("size/m"))
+			Expect(ok).To(BeTrue())
+			Expect(points).To(Equal(3.0))
+
+			points, ok = estimator.Estimate(issueWithLabels("2 pts"))
+			Expect(ok).To(BeTrue())
+			Expect(points).To(Equal(2.0))
+
+			body := "---\npoints: 5\n---\nMake the robot sigh louder."
+			issue := issueWithLabels("bug")
+			issue.Body = &body
+			points, ok = estimator.Estimate(issue)
+			Expect(ok).To(BeTrue())
+			Expect(points).To(Equal(5.0))
+		})
+
+		g.It("Should total points by assignee and list unestimated issues", func() {
+			alice := "alice"
+			estimated := issueWithLabels("3 pts")
+			estimated.Assignee = &github.User{Login: &alice}
+			unestimated := issueWithLabels("bug")
+			unestimated.Assignee = &github.User{Login: &alice}
+
+			pullRequest := issueWithLabels("points: 8")
+			pullRequest.PullRequestLinks = &github.PullRequestLinks{}
+
+			total, byAssignee := SumEstimates(defaultEstimator, []github.Issue{estimated, unestimated, issueWithLabels("points: 2"), pullRequest})
+
+			Expect(total.Issues).To(Equal(3))
+			Expect(total.Points).To(Equal(5.0))
+			Expect(total.Unestimated).To(HaveLen(1))
+			Expect(byAssignee["alice"].Points).To(Equal(3.0))
+			Expect(byAssignee[""].Points).To(Equal(2.0))
+		})
+
+		g.It("Should ignore issues without an estimate", func() {
+			_, ok := ParseEstimate(issueWithLabels("bug", "3 - In Progress"))
+
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	g.Describe("Iterations", func() {
+		g.It("Should group closed issues into windows", func() {
+			closed := func(daysAgo int, labels ...string) github.Issue {
+				return milestoneIssue(now.AddDate(0, 0, -40), timePointer(now.AddDate(0, 0, -daysAgo)), labels...)
+			}
+			issues := []github.Issue{
+				closed(1, "points: 3"),
+				closed(3, "points: 5"),
+				closed(3),
+				closed(20, "2 pts"),
+				milestoneIssue(now, nil),
+			}
+
+			iterations := WindowIterations(issues, 2, twoWeeks, now)
+
+			Expect(iterations).To(HaveLen(2))
+			Expect(iterations[0].InProgress).To(BeFalse())
+			Expect(iterations[1].InProgress).To(BeTrue())
+			Expect(iterations[0].Throughput()).To(Equal(1))
+			Expect(iterations[1].Throughput()).To(Equal(3))
+			points, estimated := iterations[1].Velocity(defaultEstimator)
+			Expect(points).To(Equal(8.0))
+			Expect(estimated).To(Equal(2))
+		})
+
+		g.It("Should pick the latest sprint milestones up to the current one", func() {
+			milestone := func(title string, dueDaysAgo int) github.Milestone {
+				return github.Milestone{Title: &title, DueOn: timePointer(now.AddDate(0, 0, -dueDaysAgo))}
+			}
+			milestones := []github.Milestone{
+				milestone("Sprint 3", -7),
+				milestone("Release 1.0", 3),
+				milestone("Sprint 1", 21),
+				milestone("Sprint 4", -21),
+				milestone("Sprint 2", 7),
+			}
+
+			sprints := SprintMilestones(milestones, 2, now)
+
+			Expect(sprints).To(HaveLen(2))
+			Expect(*sprints[0].Title).To(Equal("Sprint 2"))
+			Expect(*sprints[1].Title).To(Equal("Sprint 3"))
+		})
+	})
+}
+
+func timePointer(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,162 @@
+package githubservice
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// WIPBreach is a lane holding more issues than its work in progress limit allows,
+// either in total or, when Assignee is set, for one assignee.
+type WIPBreach struct {
+	Lane     string
+	Assignee string
+	Count    int
+	Limit    int
+}
+
+// WIPLimit is a repository's own limit for a lane, in total and for each assignee.
+// It is written as a number for the total alone or as {"wip": 3, "assigneeWip": 1},
+// and a limit of 0 leaves the lane's own limit in place.
+type WIPLimit struct {
+	WIP         int `json:"wip"`
+	AssigneeWIP int `json:"assigneeWip"`
+}
+
+func (l *WIPLimit) UnmarshalJSON(data []byte) error {
+	var total int
+	if err := json.Unmarshal(data, &total); err == nil {
+		*l = WIPLimit{WIP: total}
+		return nil
+	}
+	type limit WIPLimit
+	return json.Unmarshal(data, (*limit)(l))
+}
+
+// LaneWIPLimit is the limit of a lane in one repository: the repository's own limit
+// from limits, keyed by lane name, or else the lane's.
+func LaneWIPLimit(lane Lane, limits map[string]WIPLimit) int {
+	if limit := repoWIPLimit(lane, limits); limit.WIP > 0 {
+		return limit.WIP
+	}
+	return lane.WIP
+}
+
+// LaneAssigneeWIPLimit is the limit of each assignee in a lane of one repository,
+// found the same way as LaneWIPLimit.
+func LaneAssigneeWIPLimit(lane Lane, limits map[string]WIPLimit) int {
+	if limit := repoWIPLimit(lane, limits); limit.AssigneeWIP > 0 {
+		return limit.AssigneeWIP
+	}
+	return lane.AssigneeWIP
+}
+
+func repoWIPLimit(lane Lane, limits map[string]WIPLimit) WIPLimit {
+	for name, limit := range limits {
+		if normalizeLaneName(name) == normalizeLaneName(lane.Name) {
+			return limit
+		}
+	}
+	return WIPLimit{}
+}
+
+// WIPBreaches finds the lanes over their limits, in board order, with each lane's
+// total before the assignees over theirs. An issue counts for each of its assignees
+// in assignees, keyed by issue number, or else for its first assignee.
+func WIPBreaches(lanes []Lane, byLane map[string][]github.Issue, limits map[string]WIPLimit, assignees map[int][]string) []WIPBreach {
+	var breaches []WIPBreach
+	for _, lane := range lanes {
+		issues := byLane[lane.Name]
+		if limit := LaneWIPLimit(lane, limits); limit > 0 && len(issues) > limit {
+			breaches = append(breaches, WIPBreach{Lane: lane.Name, Count: len(issues), Limit: limit})
+		}
+
+		assigneeLimit := LaneAssigneeWIPLimit(lane, limits)
+		if assigneeLimit <= 0 {
+			continue
+		}
+		counts := make(map[string]int)
+		for _, issue := range issues {
+			for _, login := range assigneesOf(issue, assignees) {
+				counts[login]++
+			}
+		}
+		var over []string
+		for login, count := range counts {
+			if count > assigneeLimit {
+				over = append(over, login)
+			}
+		}
+		sort.Sort(loginSorter(over))
+		for _, login := range over {
+			breaches = append(breaches, WIPBreach{Lane: lane.Name, Assignee: login, Count: counts[login], Limit: assigneeLimit})
+		}
+	}
+	return breaches
+}
+
+func assigneesOf(issue github.Issue, assignees map[int][]string) []string {
+	if issue.Number != nil {
+		if logins, ok := assignees[*issue.Number]; ok {
+			return logins
+		}
+	}
+	if issue.Assignee != nil && issue.Assignee.Login != nil {
+		return []string{*issue.Assignee.Login}
+	}
+	return nil
+}
+
+// listedIssue is an issue as the list endpoint returns it, with the assignees
+// go-github doesn't know about yet.
+type listedIssue struct {
+	Number    *int          `json:"number,omitempty"`
+	Assignees []github.User `json:"assignees,omitempty"`
+}
+
+// IssueAssignees finds everyone assigned to each open issue of repo, keyed by issue
+// number, as go-github only knows about the first assignee.
+func (g *GithubService) IssueAssignees(owner string, repo string) (map[int][]string, error) {
+	var client = g.obtainAuthenticatedGithubClient()
+	assignees := make(map[int][]string)
+	page := 1
+
+	for {
+		req, err := client.NewRequest("GET", "repos/"+owner+"/"+repo+"/issues?state=open&per_page=100&page="+strconv.Itoa(page), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var issues []listedIssue
+		resp, err := client.Do(req, &issues)
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			if issue.Number == nil {
+				continue
+			}
+			for _, user := range issue.Assignees {
+				if user.Login != nil {
+					assignees[*issue.Number] = append(assignees[*issue.Number], *user.Login)
+				}
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		page = resp.NextPage
+	}
+
+	return assignees, nil
+}
+
+type loginSorter []string
+
+func (l loginSorter) Len() int           { return len(l) }
+func (l loginSorter) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+func (l loginSorter) Less(i, j int) bool { return strings.ToLower(l[i]) < strings.ToLower(l[j]) }
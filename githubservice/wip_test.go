@@ -0,0 +1,72 @@
+package githubservice
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/google/go-github/github"
+	. "github.com/onsi/gomega"
+)
+
+func assignedIssue(login string) github.Issue {
+	return github.Issue{Assignee: &github.User{Login: &login}}
+}
+
+func TestWIP(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	lanes := []Lane{
+		{Name: "Backlog"},
+		{Name: "In Progress", Keywords: []string{"in", "progress"}, WIP: 2, AssigneeWIP: 1},
+		{Name: "Ready for QA", Keywords: []string{"ready", "for", "qa"}, WIP: 5},
+	}
+	byLane := map[string][]github.Issue{
+		"Backlog":      {assignedIssue("alice"), assignedIssue("alice"), assignedIssue("alice")},
+		"In Progress":  {assignedIssue("bob"), assignedIssue("alice"), assignedIssue("bob")},
+		"Ready for QA": {assignedIssue("carol"), assignedIssue("carol")},
+	}
+
+	g.Describe("WIP limits", func() {
+		g.It("Should find lanes and assignees over their limits", func() {
+			Expect(WIPBreaches(lanes, byLane, nil, nil)).To(Equal([]WIPBreach{
+				{Lane: "In Progress", Count: 3, Limit: 2},
+				{Lane: "In Progress", Assignee: "bob", Count: 2, Limit: 1},
+			}))
+		})
+
+		g.It("Should count an issue for each of its assignees", func() {
+			number := 7
+			shared := assignedIssue("alice")
+			shared.Number = &number
+			inProgress := map[string][]github.Issue{"In Progress": {shared, assignedIssue("carol")}}
+
+			breaches := WIPBreaches(lanes, inProgress, nil, map[int][]string{7: {"alice", "carol"}})
+
+			Expect(breaches).To(Equal([]WIPBreach{{Lane: "In Progress", Assignee: "carol", Count: 2, Limit: 1}}))
+		})
+
+		g.It("Should use a repository's own limits", func() {
+			breaches := WIPBreaches(lanes, byLane, map[string]WIPLimit{"in-progress": {WIP: 3}, "ready for qa": {WIP: 1}}, nil)
+
+			Expect(breaches).To(Equal([]WIPBreach{
+				{Lane: "In Progress", Assignee: "bob", Count: 2, Limit: 1},
+				{Lane: "Ready for QA", Count: 2, Limit: 1},
+			}))
+		})
+
+		g.It("Should use a repository's own limit for each assignee", func() {
+			var limits map[string]WIPLimit
+			err := json.Unmarshal([]byte(`{"In Progress": {"wip": 4, "assigneeWip": 2}, "Ready for QA": 1}`), &limits)
+			Expect(err).To(BeNil())
+
+			breaches := WIPBreaches(lanes, byLane, limits, nil)
+
+			Expect(breaches).To(Equal([]WIPBreach{{Lane: "Ready for QA", Count: 2, Limit: 1}}))
+			Expect(LaneAssigneeWIPLimit(lanes[2], limits)).To(Equal(0))
+		})
+	})
+}
@@ -0,0 +1,81 @@
+package githubservice
+
+import (
+	"github.com/google/go-github/github"
+)
+
+// Workload is what one person has on their plate across the organization: their
+// open issues by lane and the pull requests they have open. Err says why their
+// workload couldn't be loaded.
+type Workload struct {
+	Login        string
+	ByLane       map[string][]github.Issue
+	PullRequests []github.Issue
+	Err          error
+}
+
+// NewWorkload groups the open issues assigned to login by lane.
+func NewWorkload(login string, lanes []Lane, issues []github.Issue, pullRequests []github.Issue) Workload {
+	workload := Workload{Login: login, ByLane: make(map[string][]github.Issue), PullRequests: pullRequests}
+	for _, issue := range issues {
+		if lane := LaneForIssue(lanes, issue); lane != nil {
+			workload.ByLane[lane.Name] = append(workload.ByLane[lane.Name], issue)
+		}
+	}
+	return workload
+}
+
+// Issues counts the open issues in the workload.
+func (w Workload) Issues() int {
+	count := 0
+	for _, issues := range w.ByLane {
+		count += len(issues)
+	}
+	return count
+}
+
+// Workloads searches the organization for the open issues assigned to each login
+// and the pull requests they opened. Searches are throttled to stay within GitHub's
+// search rate limit, and someone whose search fails still leaves the others.
+func (g *GithubService) Workloads(owner string, logins []string) []Workload {
+	var workloads []Workload
+	for _, login := range logins {
+		issues, err := g.searchIssues("is:open is:issue user:" + owner + " assignee:" + login)
+		if err != nil {
+			workloads = append(workloads, Workload{Login: login, Err: err})
+			continue
+		}
+		pullRequests, err := g.searchIssues("is:open is:pr user:" + owner + " author:" + login)
+		if err != nil {
+			workloads = append(workloads, Workload{Login: login, Err: err})
+			continue
+		}
+		workloads = append(workloads, NewWorkload(login, g.lanes(), issues, pullRequests))
+	}
+	return workloads
+}
+
+// UnassignedInLane finds the open issues across the organization that are in lane
+// but assigned to nobody. The search asks for the lane's label, so issues with a label
+// that only matches the lane's keywords are missed, and a lane without a label has to
+// look through every unassigned issue. Issues whose labels put them further along the
+// board are left out.
+func (g *GithubService) UnassignedInLane(owner string, lane Lane) ([]github.Issue, error) {
+	query := "is:open is:issue user:" + owner + " no:assignee"
+	if lane.Label != "" {
+		query += ` label:"` + lane.Label + `"`
+	}
+	issues, err := g.searchIssues(query)
+	if err != nil {
+		return nil, err
+	}
+
+	lanes := g.lanes()
+	var unassigned []github.Issue
+	for _, issue := range issues {
+		if found := LaneForIssue(lanes, issue); found != nil && found.Name == lane.Name {
+			unassigned = append(unassigned, issue)
+		}
+	}
+	return unassigned, nil
+}
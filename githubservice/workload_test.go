@@ -0,0 +1,34 @@
+package githubservice
+
+import (
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/google/go-github/github"
+	. "github.com/onsi/gomega"
+)
+
+func TestWorkload(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Workload", func() {
+		g.It("Should group someone's issues by lane", func() {
+			issues := []github.Issue{
+				issueWithLabels("in progress"),
+				issueWithLabels("bug"),
+				issueWithLabels("in progress", "bug"),
+			}
+			pullRequests := []github.Issue{issueWithLabels()}
+
+			workload := NewWorkload("alice", DefaultLanes, issues, pullRequests)
+
+			Expect(workload.Issues()).To(Equal(3))
+			Expect(workload.ByLane["In Progress"]).To(HaveLen(2))
+			Expect(workload.ByLane["Backlog"]).To(HaveLen(1))
+			Expect(workload.PullRequests).To(HaveLen(1))
+		})
+	})
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -14,18 +16,23 @@ import (
 func main() {
 	http.HandleFunc("/slack", SlashCommandHandler)
 	http.HandleFunc("/slack_hook", HookHandler)
+	http.HandleFunc("/slack/actions", ActionsHandler)
+	http.HandleFunc("/github/webhook", GithubWebhookHandler)
+	// Open the data store up front so a broken store stops Marvin at startup rather
+	// than on the first command that needs it.
+	robots.DataStore()
+	robots.StartScheduler()
 	StartServer()
 }
 
 func HookHandler(w http.ResponseWriter, r *http.Request) {
-	err := r.ParseForm()
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+	form, ok := verifiedForm(w, r)
+	if !ok {
 		return
 	}
 	d := schema.NewDecoder()
 	command := new(robots.OutgoingWebHook)
-	err = d.Decode(command, r.PostForm)
+	err := d.Decode(command, form)
 	if err != nil {
 		log.Println("Couldn't parse post request:", err)
 	}
@@ -43,14 +50,13 @@ func HookHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func SlashCommandHandler(w http.ResponseWriter, r *http.Request) {
-	err := r.ParseForm()
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+	form, ok := verifiedForm(w, r)
+	if !ok {
 		return
 	}
 	d := schema.NewDecoder()
 	command := new(robots.SlashCommand)
-	err = d.Decode(command, r.PostForm)
+	err := d.Decode(command, form)
 	if err != nil {
 		log.Println("Couldn't parse post request:", err)
 	}
@@ -63,6 +69,83 @@ func SlashCommandHandler(w http.ResponseWriter, r *http.Request) {
 	plainResp(w, robot.Run(&command.Payload))
 }
 
+// verifiedForm reads the form Slack posted after checking its signature, so nobody
+// else can run commands or click buttons in the name of a Slack user. It answers the
+// request itself when the form can't be trusted or read.
+func verifiedForm(w http.ResponseWriter, r *http.Request) (url.Values, bool) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, false
+	}
+	err = robots.VerifySlackSignature(r.Header, body)
+	if err != nil {
+		log.Println("Rejected Slack request:", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return nil, false
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, false
+	}
+	return form, true
+}
+
+func ActionsHandler(w http.ResponseWriter, r *http.Request) {
+	form, ok := verifiedForm(w, r)
+	if !ok {
+		return
+	}
+	interaction := new(robots.InteractionPayload)
+	err := json.Unmarshal([]byte(form.Get("payload")), interaction)
+	if err != nil {
+		log.Println("Couldn't parse interaction payload:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Slack only waits three seconds for the acknowledgement, anything the handlers
+	// have to say goes to the response_url instead.
+	w.WriteHeader(http.StatusOK)
+	for i := range interaction.Actions {
+		action := &interaction.Actions[i]
+		handler := GetAction(action.ID())
+		if handler == nil {
+			log.Println("No handler for action", action.ID())
+			continue
+		}
+		msg := handler.HandleAction(interaction, action)
+		if msg != "" {
+			response := &robots.ResponseMessage{Text: msg, ResponseType: "ephemeral"}
+			go response.Send(interaction.ResponseURL)
+		}
+	}
+}
+
+func GithubWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	err = robots.VerifyGithubSignature(r.Header, body)
+	if err != nil {
+		log.Println("Rejected GitHub webhook:", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	eventType := r.Header.Get("X-GitHub-Event")
+	w.WriteHeader(http.StatusAccepted)
+	go func() {
+		err := robots.HandleGithubEvent(eventType, body)
+		if err != nil {
+			log.Printf("Couldn't handle GitHub %s event: %s", eventType, err)
+		}
+	}()
+}
+
 func jsonResp(w http.ResponseWriter, msg string) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	resp := map[string]string{"text": msg}
@@ -95,3 +178,10 @@ func GetRobot(command string) robots.Robot {
 	}
 	return nil
 }
+
+func GetAction(actionID string) robots.ActionHandler {
+	if h, ok := robots.Actions[actionID]; ok {
+		return h
+	}
+	return nil
+}
@@ -0,0 +1,299 @@
+package robots
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+const (
+	RefreshActionID    = "refresh"
+	AssignToMeActionID = "assign_to_me"
+
+	// AttachmentCallbackID marks the legacy attachments that carry Marvin's buttons.
+	AttachmentCallbackID = "marvin_actions"
+
+	// Requests older than this are rejected so a captured request can't be replayed.
+	maxSlackRequestAge = 5 * time.Minute
+)
+
+var Actions = make(map[string]ActionHandler)
+
+// An ActionHandler is run when someone clicks a button or picks from a menu that
+// has its action id. Like Robot.Run it should return quickly and do slow work in a
+// go routine; the string it returns is shown only to the user who clicked.
+type ActionHandler interface {
+	HandleAction(i *InteractionPayload, a *InteractionAction) (botString string)
+}
+
+type InteractionPayload struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+		Name     string `json:"name"`
+	} `json:"user"`
+	Team struct {
+		ID     string `json:"id"`
+		Domain string `json:"domain"`
+	} `json:"team"`
+	Channel struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"channel"`
+	ResponseURL string              `json:"response_url"`
+	TriggerID   string              `json:"trigger_id"`
+	Actions     []InteractionAction `json:"actions"`
+}
+
+type InteractionAction struct {
+	ActionID       string `json:"action_id"`
+	Name           string `json:"name"`
+	BlockID        string `json:"block_id"`
+	Type           string `json:"type"`
+	Value          string `json:"value"`
+	SelectedOption *struct {
+		Value string `json:"value"`
+	} `json:"selected_option,omitempty"`
+}
+
+// ResponseMessage is posted to the response_url Slack sends with an interaction.
+type ResponseMessage struct {
+	Text            string  `json:"text"`
+	ResponseType    string  `json:"response_type,omitempty"`
+	ReplaceOriginal bool    `json:"replace_original"`
+	Blocks          []Block `json:"blocks,omitempty"`
+}
+
+func RegisterAction(actionID string, h ActionHandler) {
+	if _, ok := Actions[actionID]; ok {
+		log.Printf("There are two handlers mapped to action %s!", actionID)
+	} else {
+		log.Printf("Registered action: %s", actionID)
+		Actions[actionID] = h
+	}
+}
+
+func init() {
+	RegisterAction(ShowMoreActionID, &ShowMoreAction{})
+	RegisterAction(RefreshActionID, &RefreshAction{})
+	RegisterAction(AssignToMeActionID, &AssignToMeAction{})
+}
+
+// ID is the action id of a Block Kit element, or the name of a button on a legacy
+// attachment, which is where RenderAttachments puts the action id.
+func (a *InteractionAction) ID() string {
+	if a.ActionID != "" {
+		return a.ActionID
+	}
+	return a.Name
+}
+
+// SelectedValue is the value of a clicked button or of the option picked from a menu.
+func (a *InteractionAction) SelectedValue() string {
+	if a.SelectedOption != nil {
+		return a.SelectedOption.Value
+	}
+	return a.Value
+}
+
+// Payload describes the interaction the way a slash command would so that
+// actions can hand work over to robots.
+func (i *InteractionPayload) Payload() *Payload {
+	return &Payload{
+		TeamID:      i.Team.ID,
+		TeamDomain:  i.Team.Domain,
+		ChannelID:   i.Channel.ID,
+		ChannelName: i.Channel.Name,
+		UserID:      i.User.ID,
+		UserName:    i.User.Username,
+	}
+}
+
+// VerifySlackSignature checks the X-Slack-Signature of a request against the
+// signing secret from the configuration.
+func VerifySlackSignature(header http.Header, body []byte) error {
+	if Config.SigningSecret == "" {
+		return errors.New("no signing secret is configured")
+	}
+
+	timestamp, err := strconv.ParseInt(header.Get("X-Slack-Request-Timestamp"), 10, 64)
+	if err != nil {
+		return errors.New("missing request timestamp")
+	}
+	age := time.Since(time.Unix(timestamp, 0))
+	if age > maxSlackRequestAge || age < -maxSlackRequestAge {
+		return errors.New("request timestamp is too old")
+	}
+
+	mac := hmac.New(sha256.New, []byte(Config.SigningSecret))
+	mac.Write([]byte("v0:" + strconv.FormatInt(timestamp, 10) + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+func (m *ResponseMessage) Send(responseURL string) error {
+	jsonPayload, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(responseURL, "application/json", bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		message := fmt.Sprintf("ERROR: Non-200 Response from Slack response_url: %s", resp.Status)
+		log.Println(message)
+	}
+	return nil
+}
+
+// ShowMoreAction posts the next page of a result that was too big for one message.
+type ShowMoreAction struct {
+}
+
+func (h ShowMoreAction) HandleAction(i *InteractionPayload, a *InteractionAction) string {
+	id, page, ok := ParseShowMoreValue(a.Value)
+	if !ok {
+		return "I don't know which page that is."
+	}
+
+	result, ok := CachedResultPage(id, page)
+	if !ok {
+		return "That result has expired, run the command again to see it."
+	}
+
+	go func() {
+		response := &ResponseMessage{
+			Text:         result.Text,
+			ResponseType: "in_channel",
+			Blocks:       RenderBlocks(result),
+		}
+		err := response.Send(i.ResponseURL)
+		if err != nil {
+			log.Println("Couldn't send next page:", err)
+		}
+	}()
+	return ""
+}
+
+// RefreshAction runs the command that produced a result again. The value of the
+// button is the command without its slash followed by its text.
+type RefreshAction struct {
+}
+
+func RefreshButton(command string, text string) ResultAction {
+	return ResultAction{
+		ActionID: RefreshActionID,
+		Text:     "Refresh",
+		Value:    strings.TrimSpace(command + " " + text),
+	}
+}
+
+func (h RefreshAction) HandleAction(i *InteractionPayload, a *InteractionAction) string {
+	parts := strings.SplitN(a.Value, " ", 2)
+	robot, ok := Robots[parts[0]]
+	if !ok {
+		return "No robot for that command yet :("
+	}
+
+	p := i.Payload()
+	p.Robot = parts[0]
+	p.Command = "/" + parts[0]
+	if len(parts) > 1 {
+		p.Text = parts[1]
+	}
+	return robot.Run(p)
+}
+
+// AssignToMeAction assigns the issue in the value of the button, written as
+// repo#number, to the GitHub login of whoever clicked it.
+type AssignToMeAction struct {
+}
+
+func AssignToMeButton(issue github.Issue) ResultAction {
+	return ResultAction{
+		ActionID: AssignToMeActionID,
+		Text:     "Assign to me",
+		Value:    IssueReference(issue),
+	}
+}
+
+func (h AssignToMeAction) HandleAction(i *InteractionPayload, a *InteractionAction) string {
+	repo, number, err := ParseIssueReference(a.Value)
+	if err != nil {
+		return err.Error()
+	}
+	login, err := LinkedGithubLogin(i.User.ID)
+	if err != nil {
+		return err.Error()
+	}
+
+	go func() {
+		service := NewGithubService(GithubConfig)
+		_, err := AuthorizeChange(service, GithubConfig.Owner, repo, i.Payload())
+
+		var issue *github.Issue
+		if err == nil {
+			issue, err = service.AssignIssue(GithubConfig.Owner, repo, number, login)
+		}
+
+		result := Result{
+			Text:  "*" + login + "* assigned " + a.Value + " to themselves",
+			Items: BuildIssueItems(issuesOrNone(issue), err),
+		}
+		SendResult(i.Channel.ID, result)
+	}()
+	return "Assigning " + a.Value + " to " + login + "..."
+}
+
+// IssueReference writes an issue as repo#number.
+func IssueReference(issue github.Issue) string {
+	repo := ""
+	if issue.HTMLURL != nil {
+		// https://github.com/owner/repo/issues/12
+		parts := strings.Split(*issue.HTMLURL, "/")
+		if len(parts) >= 3 {
+			repo = parts[len(parts)-3]
+		}
+	}
+	return repo + "#" + strconv.Itoa(*issue.Number)
+}
+
+// ParseIssueReference reads an issue written as repo#number.
+func ParseIssueReference(reference string) (repo string, number int, err error) {
+	parts := strings.Split(strings.TrimSpace(reference), "#")
+	if len(parts) != 2 || parts[0] == "" {
+		return "", 0, errors.New("I need an issue written as repo#number, not " + reference)
+	}
+	number, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, errors.New("I need an issue written as repo#number, not " + reference)
+	}
+	return parts[0], number, nil
+}
+
+func issuesOrNone(issue *github.Issue) []github.Issue {
+	if issue == nil {
+		return nil
+	}
+	return []github.Issue{*issue}
+}
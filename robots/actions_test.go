@@ -0,0 +1,98 @@
+package robots
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func slackHeader(secret string, timestamp time.Time, body string) http.Header {
+	stamp := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + stamp + ":" + body))
+
+	header := http.Header{}
+	header.Set("X-Slack-Request-Timestamp", stamp)
+	header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return header
+}
+
+func TestActions(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	secret := "8f742231b10e8888abcd99yyyzzz85a5"
+	body := "payload=%7B%22type%22%3A%22block_actions%22%7D"
+
+	g.Describe("Slack signatures", func() {
+		g.BeforeEach(func() {
+			Config.SigningSecret = secret
+		})
+
+		g.AfterEach(func() {
+			Config.SigningSecret = ""
+		})
+
+		g.It("Should accept a request signed with the signing secret", func() {
+			Expect(VerifySlackSignature(slackHeader(secret, time.Now(), body), []byte(body))).To(Succeed())
+		})
+
+		g.It("Should refuse a tampered body or a different secret", func() {
+			Expect(VerifySlackSignature(slackHeader(secret, time.Now(), body), []byte(body+"x"))).NotTo(Succeed())
+			Expect(VerifySlackSignature(slackHeader("another secret", time.Now(), body), []byte(body))).NotTo(Succeed())
+		})
+
+		g.It("Should refuse a request without a signature or timestamp", func() {
+			header := slackHeader(secret, time.Now(), body)
+			header.Del("X-Slack-Signature")
+			Expect(VerifySlackSignature(header, []byte(body))).NotTo(Succeed())
+
+			header = slackHeader(secret, time.Now(), body)
+			header.Del("X-Slack-Request-Timestamp")
+			Expect(VerifySlackSignature(header, []byte(body))).NotTo(Succeed())
+		})
+
+		g.It("Should refuse a request signed too long ago", func() {
+			stale := time.Now().Add(-maxSlackRequestAge - time.Minute)
+			Expect(VerifySlackSignature(slackHeader(secret, stale, body), []byte(body))).NotTo(Succeed())
+		})
+
+		g.It("Should refuse everything without a signing secret", func() {
+			Config.SigningSecret = ""
+			Expect(VerifySlackSignature(slackHeader("", time.Now(), body), []byte(body))).NotTo(Succeed())
+		})
+	})
+
+	g.Describe("Actions", func() {
+		g.It("Should read the action id from buttons on legacy attachments", func() {
+			Expect((&InteractionAction{ActionID: RefreshActionID}).ID()).To(Equal(RefreshActionID))
+			Expect((&InteractionAction{Name: RefreshActionID}).ID()).To(Equal(RefreshActionID))
+		})
+	})
+
+	g.Describe("Issue references", func() {
+		g.It("Should read repo#number", func() {
+			repo, number, err := ParseIssueReference(" marvin#42 ")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repo).To(Equal("marvin"))
+			Expect(number).To(Equal(42))
+		})
+
+		g.It("Should refuse references without a repository or number", func() {
+			for _, reference := range []string{"#42", "marvin", "marvin#", "marvin#four", "a#1#2"} {
+				_, _, err := ParseIssueReference(reference)
+				Expect(err).To(HaveOccurred())
+			}
+		})
+	})
+}
@@ -9,7 +9,6 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/RobotsAndPencils/marvin/githubservice"
 	"github.com/kelseyhightower/envconfig"
 )
 
@@ -84,10 +83,10 @@ func (r AssignedBot) DeferredAction(p *Payload) {
 
 	repo, username := r.parsePayload(p)
 
-	service := githubservice.New(AssignedConfig.PersonalAccessToken)
+	service := NewGithubService(AssignedConfig)
 	issues, err := service.AssignedTo(AssignedConfig.Owner, repo, username)
 
-	attachments := BuildAttachmentsShowRepo(issues, true, false, err)
+	items := BuildIssueItemsShowRepo(issues, true, false, err)
 
 	var text string = "Assigned to *" + username + "* for repo *" + repo + "*"
 
@@ -95,22 +94,17 @@ func (r AssignedBot) DeferredAction(p *Payload) {
 		text = "Assigned to *" + username + "* for all repos."
 	}
 
-	// Let's use the IncomingWebhook struct defined in definitions.go to form and send an
+	// Let's describe the response with the Result struct defined in result.go and send it as an
 	// IncomingWebhook message to slack that can be seen by everyone in the room. You can
 	// read the Slack API Docs (https://api.slack.com/) to know which fields are required, etc.
 	// You can also see what data is available from the command structure in definitions.go
-	response := &IncomingWebhook{
-		Channel:     p.ChannelID,
-		Username:    "Marvin",
-		Text:        text,
-		IconEmoji:   ":robot:",
-		UnfurlLinks: true,
-		Parse:       ParseStyleFull,
-		Markdown:    true,
-		Attachments: attachments,
+	result := Result{
+		Text:    text,
+		Items:   items,
+		Actions: []ResultAction{RefreshButton(p.Robot, p.Text)},
 	}
 
-	response.Send()
+	SendResult(p.ChannelID, result)
 }
 
 func (r AssignedBot) Description() (description string) {
@@ -0,0 +1,229 @@
+package robots
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RobotsAndPencils/marvin/githubservice"
+	"github.com/google/go-github/github"
+	"github.com/kelseyhightower/envconfig"
+)
+
+type BoardBot struct {
+}
+
+var BoardConfig = new(GithubConfiguration)
+
+// Loads the config file and registers the bot with the server for command /board.
+func init() {
+	// Try to load the configuration from the environment and fall back to files in the filesystem
+	var c ConfigSpecification
+	err := envconfig.Process("github", &c)
+
+	if err != nil {
+		log.Println(err.Error())
+
+		// Fall back to reading from files if there is an error
+		loadBoardConfigFromFile()
+	} else {
+		err = json.Unmarshal([]byte(c.Config), BoardConfig)
+		if err != nil {
+			log.Println("error parsing config: ", err)
+			loadBoardConfigFromFile()
+		}
+	}
+	Board := &BoardBot{}
+	RegisterRobot("board", Board)
+
+	if hasWIPLimits(BoardConfig) {
+		checkTime := BoardConfig.WIPCheckTime
+		if checkTime == "" {
+			checkTime = "09:00"
+		}
+		RegisterJob("wip-check", checkTime, func(now time.Time) {
+			if !isWorkday(now) {
+				return
+			}
+			CheckAllWIPLimits(now)
+		})
+	}
+}
+
+func loadBoardConfigFromFile() {
+	flag.Parse()
+	configFile := filepath.Join(*ConfigDirectory, "github.json")
+	if _, err := os.Stat(configFile); err == nil {
+		config, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			log.Printf("ERROR: Error opening github config: %s", err)
+			return
+		}
+		err = json.Unmarshal(config, BoardConfig)
+		if err != nil {
+			log.Printf("ERROR: Error parsing github config: %s", err)
+			return
+		}
+	} else {
+		log.Printf("WARNING: Could not find configuration file github.json in %s", *ConfigDirectory)
+	}
+}
+
+var boardEstimator *githubservice.Estimator
+var boardEstimatorOnce sync.Once
+
+// BoardEstimator reads story points the way the github configuration says, falling
+// back to the default estimate labels when the configuration can't be used.
+func BoardEstimator() *githubservice.Estimator {
+	boardEstimatorOnce.Do(func() {
+		var err error
+		boardEstimator, err = githubservice.NewEstimator(GithubConfig.Estimates)
+		if err != nil {
+			log.Printf("ERROR: Invalid estimates configuration, using the defaults: %s", err)
+			boardEstimator, _ = githubservice.NewEstimator(githubservice.DefaultEstimateConfig)
+		}
+	})
+	return boardEstimator
+}
+
+// All Robots must implement a Run command to be executed when the registered command is received.
+func (r BoardBot) Run(p *Payload) string {
+	repo := strings.TrimSpace(p.Text)
+	if repo == "" {
+		return "Usage: /board repo"
+	}
+
+	// If you (optionally) want to do some asynchronous work (like sending API calls to slack)
+	// you can put it in a go routine like this
+	go r.DeferredAction(p)
+	// The string returned here will be shown only to the user who executed the command
+	// and will show up as a message from slackbot.
+
+	return "Adding up the board of " + repo + "..."
+}
+
+func (r BoardBot) DeferredAction(p *Payload) {
+
+	repo := strings.TrimSpace(p.Text)
+
+	service := NewGithubService(BoardConfig)
+	byLane, err := service.IssuesByLane(BoardConfig.Owner, repo)
+
+	var items []ResultItem
+	var all []github.Issue
+	if err != nil {
+		items = append(items, ResultItem{
+			Text:  "Error: " + err.Error(),
+			Color: "#ff0000",
+		})
+	} else {
+		breaches := githubservice.WIPBreaches(BoardLanes(), byLane, RepoWIPLimits(repo), repoAssignees(service, BoardConfig.Owner, repo))
+		for _, lane := range BoardLanes() {
+			total, byAssignee := githubservice.SumEstimates(BoardEstimator(), byLane[lane.Name])
+			all = append(all, byLane[lane.Name]...)
+			item := ResultItem{
+				Title: lane.Name,
+				Text:  describeEstimateTotals(total) + describeAssigneeTotals(byAssignee),
+				Color: "#439FE0",
+			}
+			if laneBreaches := breachesInLane(breaches, lane.Name); len(laneBreaches) > 0 {
+				item.Text = describeWIPBreaches(laneBreaches) + "\n" + item.Text
+				item.Color = "#ff1010"
+			} else if limit := githubservice.LaneWIPLimit(lane, RepoWIPLimits(repo)); limit > 0 {
+				item.Title += " (WIP " + strconv.Itoa(len(byLane[lane.Name])) + "/" + strconv.Itoa(limit) + ")"
+			}
+			items = append(items, item)
+		}
+		items = append(items, BuildEstimateItems(all)...)
+	}
+
+	total, _ := githubservice.SumEstimates(BoardEstimator(), all)
+
+	// Let's describe the response with the Result struct defined in result.go and send it as an
+	// IncomingWebhook message to slack that can be seen by everyone in the room. You can
+	// read the Slack API Docs (https://api.slack.com/) to know which fields are required, etc.
+	// You can also see what data is available from the command structure in definitions.go
+	result := Result{
+		Text:    "Board for repo *" + repo + "*: " + describeEstimateTotals(total),
+		Items:   items,
+		Actions: []ResultAction{RefreshButton(p.Robot, p.Text)},
+	}
+
+	SendResult(p.ChannelID, result)
+}
+
+// BuildEstimateItems totals the points of issues per assignee and names the first
+// few issues that have no estimate, so lane listings show how much work they hold.
+func BuildEstimateItems(issues []github.Issue) []ResultItem {
+	if len(issues) == 0 {
+		return nil
+	}
+	total, byAssignee := githubservice.SumEstimates(BoardEstimator(), issues)
+
+	items := []ResultItem{{
+		Title: "Points per assignee",
+		Text:  describeEstimateTotals(total) + describeAssigneeTotals(byAssignee),
+		Color: "#36a64f",
+	}}
+
+	if len(total.Unestimated) > 0 {
+		var references []string
+		for _, issue := range total.Unestimated {
+			references = append(references, "<"+stringValue(issue.HTMLURL)+"|"+IssueReference(issue)+">")
+		}
+		items = append(items, ResultItem{
+			Title: "Unestimated",
+			Text:  strings.Join(limitList(references), ", "),
+			Color: "#FFD334",
+		})
+	}
+	return items
+}
+
+func describeEstimateTotals(total githubservice.EstimateTotals) string {
+	text := strconv.Itoa(total.Issues) + " " + pluralize(total.Issues, "issue", "issues") + ", " + formatPoints(total.Points)
+	if len(total.Unestimated) > 0 {
+		text += " (" + strconv.Itoa(len(total.Unestimated)) + " unestimated)"
+	}
+	return text
+}
+
+// describeAssigneeTotals lists the points of each assignee, one per line, with the
+// unassigned issues last.
+func describeAssigneeTotals(byAssignee map[string]*githubservice.EstimateTotals) string {
+	var logins []string
+	for login := range byAssignee {
+		if login != "" {
+			logins = append(logins, login)
+		}
+	}
+	sort.Sort(CaseInsensitiveSorter(logins))
+	if _, ok := byAssignee[""]; ok {
+		logins = append(logins, "")
+	}
+
+	text := ""
+	for _, login := range logins {
+		name := "_" + login + "_"
+		if login == "" {
+			name = "_Unassigned_"
+		}
+		text += "\n" + name + ": " + describeEstimateTotals(*byAssignee[login])
+	}
+	return text
+}
+
+func (r BoardBot) Description() (description string) {
+	// In addition to a Run method, each Robot must implement a Description method which
+	// is just a simple string describing what the Robot does. This is used in the included
+	// /c command which gives users a list of commands and descriptions
+	return "This is a description for BoardBot which will be displayed on /c"
+}
@@ -0,0 +1,74 @@
+package robots
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/RobotsAndPencils/marvin/calendar"
+)
+
+var workCalendar *calendar.Calendar
+var workCalendarOnce sync.Once
+
+// WorkCalendar is the working calendar from the configuration, or nil when ages are
+// counted in calendar days.
+func WorkCalendar() *calendar.Calendar {
+	workCalendarOnce.Do(func() {
+		if !Config.Calendar.BusinessDays {
+			return
+		}
+
+		workWeek, err := calendar.ParseWeekdays(Config.Calendar.WorkWeek)
+		if err != nil {
+			log.Printf("ERROR: %s, using Monday to Friday", err)
+			workWeek = nil
+		}
+
+		holidays, err := calendar.ParseDates(Config.Calendar.Holidays)
+		if err != nil {
+			log.Printf("ERROR: %s", err)
+		}
+		if Config.Calendar.HolidayFile != "" {
+			days, err := loadHolidayFile(Config.Calendar.HolidayFile)
+			if err != nil {
+				log.Printf("ERROR: Couldn't read holidays from %s: %s", Config.Calendar.HolidayFile, err)
+			}
+			holidays = append(holidays, days...)
+		}
+
+		workCalendar = calendar.New(Location(), workWeek, holidays)
+	})
+	return workCalendar
+}
+
+func loadHolidayFile(path string) ([]time.Time, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(*ConfigDirectory, path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return calendar.ParseICS(file)
+}
+
+// AgeInDays is how long ago t was, in business days when the configuration asks
+// for them.
+func AgeInDays(t time.Time) float64 {
+	if c := WorkCalendar(); c != nil {
+		return c.BusinessDays(t, time.Now())
+	}
+	return time.Since(t).Hours() / 24
+}
+
+// DayUnit names the days AgeInDays counts.
+func DayUnit() string {
+	if WorkCalendar() != nil {
+		return "business days"
+	}
+	return "days"
+}
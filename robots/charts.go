@@ -0,0 +1,95 @@
+package robots
+
+import (
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/RobotsAndPencils/marvin/chart"
+	"github.com/RobotsAndPencils/marvin/githubservice"
+	"github.com/RobotsAndPencils/marvin/upload"
+	"github.com/google/go-github/github"
+)
+
+var fileUploader upload.Uploader
+var fileUploaderOnce sync.Once
+
+// FileUploader posts files to Slack with the configured bot token. Without a token
+// files are saved in the charts folder of the configuration directory instead, so
+// the commands that draw charts still work while Marvin is being set up.
+func FileUploader() upload.Uploader {
+	fileUploaderOnce.Do(func() {
+		if Config.BotToken != "" {
+			fileUploader = upload.NewSlack(Config.BotToken)
+		} else {
+			directory := filepath.Join(*ConfigDirectory, "charts")
+			log.Printf("WARNING: No bottoken configured, saving charts in %s", directory)
+			fileUploader = &upload.Local{Directory: directory}
+		}
+	})
+	return fileUploader
+}
+
+// SendChart draws a chart and posts it to channel as a PNG image.
+func SendChart(channel string, filename string, c chart.Chart) error {
+	content, err := c.PNG()
+	if err != nil {
+		return err
+	}
+
+	err = FileUploader().Upload(upload.File{
+		Channel:  channel,
+		Filename: filename,
+		Title:    c.Title,
+		Content:  content,
+	})
+	if err != nil {
+		log.Printf("ERROR: Couldn't upload %s: %s", filename, err)
+	}
+	return err
+}
+
+// FlowChart stacks the daily lane counts of a repository, with the lanes at the end
+// of the board at the bottom.
+func FlowChart(repo string, snapshots []FlowSnapshot, lanes []githubservice.Lane) chart.Chart {
+	var dates []string
+	for _, snapshot := range snapshots {
+		date, _ := time.Parse(flowDateFormat, snapshot.Date)
+		dates = append(dates, date.Format("Jan 2"))
+	}
+
+	var series []chart.Series
+	for i := len(lanes) - 1; i >= 0; i-- {
+		var values []float64
+		for _, snapshot := range snapshots {
+			values = append(values, float64(snapshot.Counts[lanes[i].Name]))
+		}
+		series = append(series, chart.Series{Name: lanes[i].Name, Values: values})
+	}
+	return chart.CumulativeFlow("Flow of "+repo, dates, series)
+}
+
+// PullRequestAgeChart counts pull requests by how long they have been open, in
+// business days when the configuration asks for them.
+func PullRequestAgeChart(pullRequests []github.PullRequest, now time.Time) chart.Chart {
+	buckets := []string{"<1d", "1-3d", "4-7d", "8-30d", ">30d"}
+	limits := []float64{1, 4, 8, 31}
+
+	counts := make([]float64, len(buckets))
+	for _, pullRequest := range pullRequests {
+		days := now.Sub(*pullRequest.CreatedAt).Hours() / 24
+		if calendar := WorkCalendar(); calendar != nil {
+			days = calendar.BusinessDays(*pullRequest.CreatedAt, now)
+		}
+		bucket := len(limits)
+		for i, limit := range limits {
+			if days < limit {
+				bucket = i
+				break
+			}
+		}
+		counts[bucket]++
+	}
+	return chart.Histogram("Open pull requests by age", buckets, counts)
+}
@@ -10,7 +10,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/RobotsAndPencils/marvin/githubservice"
 	"github.com/kelseyhightower/envconfig"
 )
 
@@ -67,12 +66,12 @@ func (r CommitsToMasterBot) Run(p *Payload) string {
 	go r.DeferredAction(p)
 	// The string returned here will be shown only to the user who executed the command
 	// and will show up as a message from slackbot.
-	repo := strings.TrimSpace(p.Text)
+	repo, branch := parseCommitsToMasterArguments(p.Text)
 
 	if repo == "" {
-		return "Calculating commits to master weekly report..."
+		return "Calculating commits to " + branchOrDefault(branch) + " weekly report..."
 	} else {
-		return "Calculating commits to master for " + p.Text + "..."
+		return "Calculating commits to " + branchOrDefault(branch) + " for " + repo + "..."
 	}
 
 }
@@ -80,39 +79,57 @@ func (r CommitsToMasterBot) Run(p *Payload) string {
 func (r CommitsToMasterBot) DeferredAction(p *Payload) {
 
 	days := 30 //default to last 30 days
-	repo := strings.TrimSpace(p.Text)
+	repo, branch := parseCommitsToMasterArguments(p.Text)
 	if repo == "" {
 		days = 7 //when searching all repos use a time box of 7 days
 	}
 
-	responseText := "Commits to master in the last " + strconv.Itoa(days) + " days"
-	service := githubservice.New(CommitsToMasterConfig.PersonalAccessToken)
-	reposToCommits, _, err := service.CommitsToMaster(CommitsToMasterConfig.Owner, repo, days)
-	var attachments []Attachment
+	responseText := "Commits to " + branchOrDefault(branch) + " in the last " + strconv.Itoa(days) + " days"
+	service := NewGithubService(CommitsToMasterConfig)
+	reposToCommits, _, err := service.CommitsToMaster(CommitsToMasterConfig.Owner, repo, branch, days)
+	var items []ResultItem
 
 	if repo != "" {
-		responseText = "Commits to master for repo *" + repo + "* in the last " + strconv.Itoa(days) + " days"
-		attachments = BuildAttachmentsShowCommits(reposToCommits, err)
+		if err == nil {
+			branch = reposToCommits[repo].Branch
+		}
+		responseText = "Commits to " + branchOrDefault(branch) + " for repo *" + repo + "* in the last " + strconv.Itoa(days) + " days"
+		items = BuildCommitItems(reposToCommits, err)
 	} else {
-		attachments = BuildAttachmentCommitSummaryByRepo(reposToCommits, CommitsToMasterConfig.Owner, days)
+		items = BuildCommitSummaryItemsByRepo(reposToCommits, CommitsToMasterConfig.Owner, days)
 	}
 
-	// Let's use the IncomingWebhook struct defined in definitions.go to form and send an
+	// Let's describe the response with the Result struct defined in result.go and send it as an
 	// IncomingWebhook message to slack that can be seen by everyone in the room. You can
 	// read the Slack API Docs (https://api.slack.com/) to know which fields are required, etc.
 	// You can also see what data is available from the command structure in definitions.go
-	response := &IncomingWebhook{
-		Channel:     p.ChannelID,
-		Username:    "Marvin",
-		Text:        responseText,
-		IconEmoji:   ":robot:",
-		UnfurlLinks: true,
-		Parse:       ParseStyleFull,
-		Markdown:    true,
-		Attachments: attachments,
+	result := Result{
+		Text:    responseText,
+		Items:   items,
+		Actions: []ResultAction{RefreshButton(p.Robot, p.Text)},
+	}
+
+	SendResult(p.ChannelID, result)
+}
+
+// parseCommitsToMasterArguments reads "[repo|*] [branch]". An empty repo means every
+// active repository and an empty branch means each repository's default branch.
+func parseCommitsToMasterArguments(text string) (repo string, branch string) {
+	args := strings.Fields(text)
+	if len(args) > 0 && args[0] != "*" {
+		repo = args[0]
+	}
+	if len(args) > 1 {
+		branch = args[1]
 	}
+	return repo, branch
+}
 
-	response.Send()
+func branchOrDefault(branch string) string {
+	if branch == "" {
+		return "the default branch"
+	}
+	return branch
 }
 
 func (r CommitsToMasterBot) Description() (description string) {
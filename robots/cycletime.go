@@ -0,0 +1,207 @@
+package robots
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RobotsAndPencils/marvin/githubservice"
+	"github.com/kelseyhightower/envconfig"
+)
+
+type CycleTimeBot struct {
+}
+
+var CycleTimeConfig = new(GithubConfiguration)
+
+// Loads the config file and registers the bot with the server for command /cycletime.
+func init() {
+	// Try to load the configuration from the environment and fall back to files in the filesystem
+	var c ConfigSpecification
+	err := envconfig.Process("github", &c)
+
+	if err != nil {
+		log.Println(err.Error())
+
+		// Fall back to reading from files if there is an error
+		loadCycleTimeConfigFromFile()
+	} else {
+		err = json.Unmarshal([]byte(c.Config), CycleTimeConfig)
+		if err != nil {
+			log.Println("error parsing config: ", err)
+			loadCycleTimeConfigFromFile()
+		}
+	}
+	CycleTime := &CycleTimeBot{}
+	RegisterRobot("cycletime", CycleTime)
+}
+
+func loadCycleTimeConfigFromFile() {
+	flag.Parse()
+	configFile := filepath.Join(*ConfigDirectory, "github.json")
+	if _, err := os.Stat(configFile); err == nil {
+		config, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			log.Printf("ERROR: Error opening github config: %s", err)
+			return
+		}
+		err = json.Unmarshal(config, CycleTimeConfig)
+		if err != nil {
+			log.Printf("ERROR: Error parsing github config: %s", err)
+			return
+		}
+	} else {
+		log.Printf("WARNING: Could not find configuration file github.json in %s", *ConfigDirectory)
+	}
+}
+
+func (r CycleTimeBot) parsePayload(p *Payload) (repo string, days int, err error) {
+	args, flags := ParseArguments(p.Text)
+	if len(args) != 1 {
+		return "", 0, errors.New("Usage: /cycletime repo [--since 30d]")
+	}
+
+	days = 30
+	if since, ok := flags["since"]; ok {
+		days, err = ParseDays(since)
+	}
+	return args[0], days, err
+}
+
+// All Robots must implement a Run command to be executed when the registered command is received.
+func (r CycleTimeBot) Run(p *Payload) string {
+	repo, days, err := r.parsePayload(p)
+	if err != nil {
+		return err.Error()
+	}
+
+	// If you (optionally) want to do some asynchronous work (like sending API calls to slack)
+	// you can put it in a go routine like this
+	go r.DeferredAction(p)
+	// The string returned here will be shown only to the user who executed the command
+	// and will show up as a message from slackbot.
+
+	return "Working out cycle times for " + repo + " over the last " + strconv.Itoa(days) + " days..."
+}
+
+func (r CycleTimeBot) DeferredAction(p *Payload) {
+
+	repo, days, _ := r.parsePayload(p)
+	since := time.Now().AddDate(0, 0, -days)
+
+	service := NewGithubService(CycleTimeConfig)
+	histories, skipped, err := service.IssueHistories(CycleTimeConfig.Owner, repo, since)
+
+	var items []ResultItem
+	if err != nil {
+		items = append(items, ResultItem{
+			Text:  "Error: " + err.Error(),
+			Color: "#ff0000",
+		})
+	} else {
+		items = BuildCycleTimeItems(histories, BoardLanes())
+	}
+
+	// Let's describe the response with the Result struct defined in result.go and send it as an
+	// IncomingWebhook message to slack that can be seen by everyone in the room. You can
+	// read the Slack API Docs (https://api.slack.com/) to know which fields are required, etc.
+	// You can also see what data is available from the command structure in definitions.go
+	result := Result{
+		Text:    "Cycle time for *" + repo + "* over the last " + strconv.Itoa(days) + " days (" + strconv.Itoa(len(histories)) + " " + pluralize(len(histories), "issue", "issues") + ")",
+		Items:   items,
+		Actions: []ResultAction{RefreshButton(p.Robot, p.Text)},
+	}
+	if skipped > 0 {
+		result.Footer = "Only the " + strconv.Itoa(len(histories)) + " most recently updated issues are counted, " + strconv.Itoa(skipped) + " more changed in this period."
+	}
+
+	SendResult(p.ChannelID, result)
+}
+
+// BuildCycleTimeItems reports the median and 85th percentile time spent in each lane,
+// in board order, followed by lead and cycle times and the issues that took far longer
+// than the rest.
+func BuildCycleTimeItems(histories []githubservice.IssueHistory, lanes []githubservice.Lane) []ResultItem {
+	var items []ResultItem
+
+	laneTimes := make(map[string][]time.Duration)
+	var leadTimes, cycleTimes []time.Duration
+	for _, history := range histories {
+		for lane, duration := range history.TimeInLanes() {
+			laneTimes[lane] = append(laneTimes[lane], duration)
+		}
+		if leadTime, ok := history.LeadTime(); ok {
+			leadTimes = append(leadTimes, leadTime)
+		}
+		if cycleTime, ok := history.CycleTime(lanes); ok {
+			cycleTimes = append(cycleTimes, cycleTime)
+		}
+	}
+
+	for _, lane := range lanes {
+		if len(laneTimes[lane.Name]) == 0 {
+			continue
+		}
+		items = append(items, percentileItem(lane.Name, laneTimes[lane.Name], "#439FE0"))
+	}
+	if len(leadTimes) > 0 {
+		items = append(items, percentileItem("Lead time (opened to closed)", leadTimes, "#36a64f"))
+	}
+	if len(cycleTimes) > 0 {
+		items = append(items, percentileItem("Cycle time (started to closed)", cycleTimes, "#36a64f"))
+	}
+
+	var outliers []string
+	for _, lane := range lanes {
+		if lane.IsCatchAll() || len(laneTimes[lane.Name]) < 4 {
+			continue
+		}
+		threshold := githubservice.OutlierThreshold(laneTimes[lane.Name])
+		for _, history := range histories {
+			if duration := history.TimeInLanes()[lane.Name]; duration > threshold {
+				outliers = append(outliers, "<"+*history.Issue.HTMLURL+"|"+IssueReference(history.Issue)+"> spent "+FormatDuration(duration)+" in "+lane.Name)
+			}
+		}
+	}
+	if len(outliers) > 0 {
+		items = append(items, ResultItem{
+			Title: "Outliers",
+			Text:  strings.Join(outliers, "\n"),
+			Color: "#ff1010",
+		})
+	}
+
+	if len(items) == 0 {
+		items = append(items, ResultItem{
+			Text:  "No issues moved in this period",
+			Color: "#A0A0A0",
+		})
+	}
+	return items
+}
+
+func percentileItem(title string, durations []time.Duration, color string) ResultItem {
+	return ResultItem{
+		Title: title,
+		Text:  strconv.Itoa(len(durations)) + " " + pluralize(len(durations), "issue", "issues"),
+		Color: color,
+		Fields: []ResultField{
+			{Title: "Median", Value: FormatDuration(githubservice.Percentile(durations, 50)), Short: true},
+			{Title: "85th percentile", Value: FormatDuration(githubservice.Percentile(durations, 85)), Short: true},
+		},
+	}
+}
+
+func (r CycleTimeBot) Description() (description string) {
+	// In addition to a Run method, each Robot must implement a Description method which
+	// is just a simple string describing what the Robot does. This is used in the included
+	// /c command which gives users a list of commands and descriptions
+	return "This is a description for CycleTimeBot which will be displayed on /c"
+}
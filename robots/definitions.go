@@ -1,5 +1,9 @@
 package robots
 
+import (
+	"github.com/RobotsAndPencils/marvin/githubservice"
+)
+
 type ConfigSpecification struct {
 	Config string
 }
@@ -48,6 +52,7 @@ type IncomingWebhook struct {
 	IconEmoji   string       `json:"icon_emoji,omitempty"`
 	IconURL     string       `json:"icon_url,omitempty"`
 	Attachments []Attachment `json:"attachments,omitempty"`
+	Blocks      []Block      `json:"blocks,omitempty"`
 	UnfurlLinks bool         `json:"unfurl_links,omitempty"`
 	Parse       ParseStyle   `json:"parse,omitempty"`
 	LinkNames   bool         `json:"link_names,omitempty"`
@@ -55,14 +60,27 @@ type IncomingWebhook struct {
 }
 
 type Attachment struct {
-	Fallback   string            `json:"fallback"`
-	Pretext    string            `json:"pretext,omitempty"`
-	Text       string            `json:"text,omitempty"`
-	Color      string            `json:"color,omitempty"`
-	Fields     []AttachmentField `json:"fields,omitempty"`
-	MarkdownIn []MarkdownField   `json:"mrkdwn_in,omitempty"`
-	Title      string            `json:"title,omitempty"`
-	TitleLink  string            `json:"title_link,omitempty"`
+	Fallback   string             `json:"fallback"`
+	Pretext    string             `json:"pretext,omitempty"`
+	Text       string             `json:"text,omitempty"`
+	Color      string             `json:"color,omitempty"`
+	Fields     []AttachmentField  `json:"fields,omitempty"`
+	MarkdownIn []MarkdownField    `json:"mrkdwn_in,omitempty"`
+	Title      string             `json:"title,omitempty"`
+	TitleLink  string             `json:"title_link,omitempty"`
+	CallbackID string             `json:"callback_id,omitempty"`
+	Actions    []AttachmentAction `json:"actions,omitempty"`
+}
+
+// AttachmentAction is a button on a legacy attachment. Clicks come back to Marvin
+// with the action id as the Name.
+type AttachmentAction struct {
+	Name  string `json:"name"`
+	Text  string `json:"text"`
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+	URL   string `json:"url,omitempty"`
+	Style string `json:"style,omitempty"`
 }
 
 type MarkdownField string
@@ -81,11 +99,79 @@ type AttachmentField struct {
 	Short bool   `json:"short,omitempty"`
 }
 
+type Block struct {
+	Type      string        `json:"type"`
+	BlockID   string        `json:"block_id,omitempty"`
+	Text      *TextObject   `json:"text,omitempty"`
+	Fields    []TextObject  `json:"fields,omitempty"`
+	Accessory *BlockElement `json:"accessory,omitempty"`
+	Elements  []interface{} `json:"elements,omitempty"`
+}
+
+var (
+	BlockTypeSection = "section"
+	BlockTypeContext = "context"
+	BlockTypeDivider = "divider"
+	BlockTypeActions = "actions"
+)
+
+type TextObject struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Emoji bool   `json:"emoji,omitempty"`
+}
+
+var (
+	TextTypePlain    = "plain_text"
+	TextTypeMarkdown = "mrkdwn"
+)
+
+type BlockElement struct {
+	Type     string      `json:"type"`
+	Text     *TextObject `json:"text,omitempty"`
+	ActionID string      `json:"action_id,omitempty"`
+	Value    string      `json:"value,omitempty"`
+	URL      string      `json:"url,omitempty"`
+	Style    string      `json:"style,omitempty"`
+}
+
 type Configuration struct {
-	Domain      string `schema:"domain"`
-	Port        int    `schema:"port"`
-	Token       string `schema:"token"`
-	WebHookPath string `schema:"webhookpath"`
+	Domain          string                `schema:"domain"`
+	Port            int                   `schema:"port"`
+	Token           string                `schema:"token"`
+	WebHookPath     string                `schema:"webhookpath"`
+	BlockKit        bool                  `schema:"blockkit"`
+	PageSize        int                   `schema:"pagesize"`
+	SigningSecret   string                `schema:"signingsecret"`
+	Identities      map[string]string     `schema:"identities"`
+	Subscriptions   []Subscription        `schema:"subscriptions"`
+	StorePath       string                `schema:"storepath"`
+	StoreURL        string                `schema:"storeurl"`
+	StoreCA         string                `schema:"storeca"`
+	StoreSkipVerify bool                  `schema:"storeskipverify"`
+	TimeZone        string                `schema:"timezone"`
+	BotToken        string                `schema:"bottoken"`
+	Calendar        CalendarConfiguration `schema:"calendar"`
+}
+
+// StaleReport posts /stale for Repo to Channel every day At a time written as 15:04,
+// optionally mentioning the assignees.
+type StaleReport struct {
+	Repo    string `json:"repo"`
+	Channel string `json:"channel"`
+	At      string `json:"at"`
+	Mention bool   `json:"mention"`
+}
+
+// CalendarConfiguration sets up counting ages in business days. WorkWeek lists the
+// days worked, Monday to Friday by default. Holidays are dates written as 2006-01-02
+// and HolidayFile is an iCalendar file of days off, relative to the configuration
+// directory.
+type CalendarConfiguration struct {
+	BusinessDays bool     `schema:"businessDays"`
+	WorkWeek     []string `schema:"workWeek"`
+	Holidays     []string `schema:"holidays"`
+	HolidayFile  string   `schema:"holidayFile"`
 }
 
 type Robot interface {
@@ -94,6 +180,22 @@ type Robot interface {
 }
 
 type GithubConfiguration struct {
-	Owner               string `schema:"owner"`
-	PersonalAccessToken string `schema:"personalAccessToken"`
+	Owner               string                       `schema:"owner"`
+	PersonalAccessToken string                       `schema:"personalAccessToken"`
+	AppID               int                          `schema:"appId"`
+	PrivateKey          string                       `schema:"privateKey"`
+	PrivateKeyPath      string                       `schema:"privateKeyPath"`
+	Installations       map[string]int               `schema:"installations"`
+	Lanes               []githubservice.Lane         `schema:"lanes"`
+	WebhookSecret       string                       `schema:"webhookSecret"`
+	FlowRepos           []string                     `schema:"flowRepos"`
+	FlowSnapshotTime    string                       `schema:"flowSnapshotTime"`
+	SprintLength        int                          `schema:"sprintLength"`
+	Estimates           githubservice.EstimateConfig `schema:"estimates"`
+	RepoGroups          map[string][]string          `schema:"repoGroups"`
+	StaleDays           int                          `schema:"staleDays"`
+	StaleReports        []StaleReport                `schema:"staleReports"`
+	WIPLimits           map[string]LaneWIPLimits     `schema:"wipLimits"`
+	WIPCheckTime        string                       `schema:"wipCheckTime"`
+	Teams               map[string][]string          `schema:"teams"`
 }
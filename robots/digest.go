@@ -0,0 +1,283 @@
+package robots
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RobotsAndPencils/marvin/githubservice"
+	"github.com/google/go-github/github"
+)
+
+const digestsBucket = "digests"
+
+// Digest is someone's opt-in to a direct message every workday with what is on
+// their plate. Assigned remembers the issues assigned to them at the last digest,
+// so the next one can point out what is new.
+type Digest struct {
+	UserID   string   `json:"userId"`
+	UserName string   `json:"userName"`
+	Login    string   `json:"login"`
+	At       string   `json:"at"`
+	Assigned []string `json:"assigned"`
+}
+
+func digestJobName(userID string) string {
+	return "digest-" + userID
+}
+
+// EnableDigest sends the user behind p a digest every workday at the given time,
+// written as 9:00 or 15:04. Asking again changes the time.
+func EnableDigest(p *Payload, at string) (*Digest, error) {
+	if Config.BotToken == "" {
+		return nil, errors.New("digests are sent with the bot token, and there is no bottoken in the configuration")
+	}
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return nil, errors.New("I can't read " + at + " as a time of day, try 9:00")
+	}
+	login, err := GithubLogin(p.UserID, p.UserName)
+	if err != nil {
+		return nil, err
+	}
+
+	digest, _ := LoadDigest(p.UserID)
+	if digest == nil {
+		digest = &Digest{UserID: p.UserID}
+	}
+	digest.UserName = p.UserName
+	digest.Login = login
+	digest.At = t.Format("15:04")
+
+	err = DataStore().Put(digestsBucket, p.UserID, digest)
+	if err != nil {
+		return nil, err
+	}
+	return digest, scheduleDigest(*digest)
+}
+
+// DisableDigest stops the digest of a user.
+func DisableDigest(userID string) error {
+	UnscheduleJob(digestJobName(userID))
+	return DataStore().Delete(digestsBucket, userID)
+}
+
+// LoadDigest finds the digest a user opted in to, or nil when they haven't.
+func LoadDigest(userID string) (*Digest, error) {
+	var digest Digest
+	found, err := DataStore().Get(digestsBucket, userID, &digest)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &digest, nil
+}
+
+// ScheduleDigests schedules the digests everyone opted in to.
+func ScheduleDigests() {
+	for _, userID := range DataStore().Keys(digestsBucket) {
+		digest, err := LoadDigest(userID)
+		if err != nil || digest == nil {
+			log.Printf("ERROR: Couldn't load the digest of %s: %s", userID, err)
+			continue
+		}
+		err = scheduleDigest(*digest)
+		if err != nil {
+			log.Printf("ERROR: %s", err)
+		}
+	}
+}
+
+func scheduleDigest(digest Digest) error {
+	return ScheduleJob(digestJobName(digest.UserID), digest.At, func(now time.Time) {
+		if !isWorkday(now) {
+			return
+		}
+		SendDigest(digest.UserID)
+	})
+}
+
+// isWorkday is true on business days of the working calendar, or on weekdays
+// when ages aren't counted in business days.
+func isWorkday(t time.Time) bool {
+	if c := WorkCalendar(); c != nil {
+		return c.IsBusinessDay(t)
+	}
+	return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+}
+
+// Digests usually fire in the same minute and each runs a few GitHub searches, which
+// are limited to 30 a minute, so they are sent one at a time.
+var digestMutex sync.Mutex
+
+// SendDigest sends a user their digest right away, as a direct message.
+func SendDigest(userID string) {
+	digestMutex.Lock()
+	defer digestMutex.Unlock()
+
+	digest, err := LoadDigest(userID)
+	if err != nil || digest == nil {
+		log.Printf("ERROR: Couldn't load the digest of %s: %s", userID, err)
+		return
+	}
+
+	service := NewGithubService(GithubConfig)
+	var items []ResultItem
+
+	assigned, err := service.AssignedTo(GithubConfig.Owner, "*", digest.Login)
+	if err != nil {
+		items = append(items, ResultItem{Text: "Error: " + err.Error(), Color: "#ff0000"})
+	}
+	assigned = openIssues(assigned)
+
+	reviews, err := service.ReviewQueue(GithubConfig.Owner, digest.Login)
+	if err != nil {
+		items = append(items, ResultItem{Text: "Error: " + err.Error(), Color: "#ff0000"})
+	}
+
+	changes, err := service.ChangesRequestedOf(GithubConfig.Owner, digest.Login)
+	if err != nil {
+		items = append(items, ResultItem{Text: "Error: " + err.Error(), Color: "#ff0000"})
+	}
+
+	newlyAssigned := NewlyAssigned(digest.Assigned, assigned)
+	items = append(items, BuildDigestItems(assigned, newlyAssigned, reviews, changes, BoardLanes())...)
+
+	result := Result{
+		Text:  "Good morning " + digest.Login + ", here is what's on your plate today",
+		Items: items,
+	}
+	err = SendDirectMessage(digest.UserID, result)
+	if err != nil {
+		log.Printf("ERROR: Couldn't send the digest of %s: %s", digest.Login, err)
+		return
+	}
+
+	digest.Assigned = nil
+	for _, issue := range assigned {
+		digest.Assigned = append(digest.Assigned, *issue.HTMLURL)
+	}
+	err = DataStore().Put(digestsBucket, digest.UserID, digest)
+	if err != nil {
+		log.Printf("ERROR: Couldn't save the digest of %s: %s", digest.Login, err)
+	}
+}
+
+func openIssues(issues []github.Issue) []github.Issue {
+	var open []github.Issue
+	for _, issue := range issues {
+		if issue.State == nil || *issue.State == "open" {
+			open = append(open, issue)
+		}
+	}
+	return open
+}
+
+// NewlyAssigned picks the issues that weren't assigned at the last digest. Nothing
+// is new in the first digest.
+func NewlyAssigned(previous []string, assigned []github.Issue) []github.Issue {
+	if previous == nil {
+		return nil
+	}
+	var newlyAssigned []github.Issue
+	for _, issue := range assigned {
+		if !contains(previous, *issue.HTMLURL) {
+			newlyAssigned = append(newlyAssigned, issue)
+		}
+	}
+	return newlyAssigned
+}
+
+// BuildDigestItems describes the issues assigned to someone by lane, in board order,
+// what was assigned since the last digest, the pull requests waiting on their
+// review and their pull requests where changes were requested.
+func BuildDigestItems(assigned []github.Issue, newlyAssigned []github.Issue, reviews []githubservice.ReviewRequest, changes []github.Issue, lanes []githubservice.Lane) []ResultItem {
+	var items []ResultItem
+
+	if len(newlyAssigned) > 0 {
+		items = append(items, ResultItem{
+			Title: "Newly assigned since yesterday",
+			Text:  issueLines(newlyAssigned),
+			Color: "#36a64f",
+		})
+	}
+
+	byLane := make(map[string][]github.Issue)
+	for _, issue := range assigned {
+		name := laneName(githubservice.LaneForIssue(lanes, issue))
+		byLane[name] = append(byLane[name], issue)
+	}
+	var laneNames []string
+	for _, lane := range lanes {
+		laneNames = append(laneNames, lane.Name)
+	}
+	// Without a catch-all lane some issues are in no lane at all.
+	laneNames = append(laneNames, laneName(nil))
+	for _, name := range laneNames {
+		issues := byLane[name]
+		if len(issues) == 0 {
+			continue
+		}
+		items = append(items, ResultItem{
+			Title: "Assigned to you in " + name + " (" + strconv.Itoa(len(issues)) + ")",
+			Text:  issueLines(issues),
+			Color: "#439FE0",
+		})
+	}
+
+	if len(reviews) > 0 {
+		var lines []string
+		for _, request := range reviews {
+			days := AgeInDays(*request.PullRequest.CreatedAt)
+			lines = append(lines, issueLine(request.PullRequest)+" _"+strconv.FormatFloat(days, 'f', 0, 64)+" "+DayUnit()+" old, "+request.Reason+"_")
+		}
+		// The queue is oldest first, so the first one sets the colour.
+		items = append(items, ResultItem{
+			Title: "Waiting on your review (" + strconv.Itoa(len(reviews)) + ")",
+			Text:  strings.Join(limitList(lines), "\n"),
+			Color: colorForPullRequestAge(AgeInDays(*reviews[0].PullRequest.CreatedAt)),
+		})
+	}
+
+	if len(changes) > 0 {
+		items = append(items, ResultItem{
+			Title: "Changes requested on your pull requests (" + strconv.Itoa(len(changes)) + ")",
+			Text:  issueLines(changes),
+			Color: "#ff9f9f",
+		})
+	}
+
+	if len(items) == 0 {
+		items = append(items, ResultItem{
+			Text:  "Nothing is assigned to you or waiting on you today",
+			Color: "#A0A0A0",
+		})
+	}
+	return items
+}
+
+// issueLines lists the first few issues, one per line, in the order of their references.
+func issueLines(issues []github.Issue) string {
+	sorted := append([]github.Issue{}, issues...)
+	sort.Sort(issuesByReference(sorted))
+	var lines []string
+	for _, issue := range sorted {
+		lines = append(lines, issueLine(issue))
+	}
+	return strings.Join(limitList(lines), "\n")
+}
+
+func issueLine(issue github.Issue) string {
+	return "• <" + *issue.HTMLURL + "|" + IssueReference(issue) + "> " + *issue.Title
+}
+
+type issuesByReference []github.Issue
+
+func (s issuesByReference) Len() int      { return len(s) }
+func (s issuesByReference) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s issuesByReference) Less(i, j int) bool {
+	return IssueReference(s[i]) < IssueReference(s[j])
+}
@@ -0,0 +1,83 @@
+package robots
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+)
+
+var slackAPIURL = "https://slack.com/api/"
+
+type slackAPIResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+type conversationsOpenResponse struct {
+	Channel struct {
+		ID string `json:"id"`
+	} `json:"channel"`
+}
+
+// SendDirectMessage sends a result to a user in a direct message from the bot.
+// Incoming webhooks only post to the channel they were made for, so this opens the
+// conversation with the Web API using the bot token, which needs the im:write and
+// chat:write scopes.
+func SendDirectMessage(userID string, result Result) error {
+	if Config.BotToken == "" {
+		return errors.New("direct messages need a bottoken in the configuration")
+	}
+
+	var conversation conversationsOpenResponse
+	err := callSlackAPI("conversations.open", map[string]string{"users": userID}, &conversation)
+	if err != nil {
+		return err
+	}
+
+	return sendPages(result, func(page Result) error {
+		return callSlackAPI("chat.postMessage", NewIncomingWebhook(conversation.Channel.ID, page), nil)
+	})
+}
+
+// callSlackAPI posts payload as JSON to a Slack Web API method with the bot token and
+// reads the response into v when it isn't nil. Slack reports failures in the body
+// rather than with the status code.
+func callSlackAPI(method string, payload interface{}, v interface{}) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", slackAPIURL+method, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+Config.BotToken)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	contents, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var status slackAPIResponse
+	err = json.Unmarshal(contents, &status)
+	if err != nil {
+		return errors.New("Slack sent an unexpected response to " + method + ": " + resp.Status)
+	}
+	if !status.OK {
+		return errors.New("Slack error from " + method + ": " + status.Error)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal(contents, v)
+}
@@ -0,0 +1,70 @@
+package robots
+
+import (
+	"log"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// handleDirectPush alerts the channels subscribed to direct pushes when commits land
+// on a repository's default branch without coming through a pull request. It is the
+// live counterpart of /commitstomaster, checking only the commits in the push.
+func handleDirectPush(event *github.PushEvent) {
+	if event.Repo.DefaultBranch == nil || *event.Ref != "refs/heads/"+*event.Repo.DefaultBranch {
+		return
+	}
+	if event.Repo.FullName == nil || len(SubscribedChannels(*event.Repo.Name, SubscribeDirectPushes)) == 0 {
+		return
+	}
+	owner := strings.Split(*event.Repo.FullName, "/")[0]
+	repo := *event.Repo.Name
+	branch := *event.Repo.DefaultBranch
+
+	service := NewGithubService(GithubConfig)
+
+	var items []ResultItem
+	for _, commit := range event.Commits {
+		if commit.Distinct != nil && !*commit.Distinct {
+			// Already on another branch, so it was pushed before and judged then.
+			continue
+		}
+
+		sha := commitID(commit)
+		pullRequests, err := service.PullRequestsForCommit(owner, repo, sha)
+		if err != nil {
+			log.Printf("ERROR: Couldn't find pull requests for %s/%s: %s", repo, sha, err)
+			continue
+		}
+		if len(pullRequests) > 0 {
+			continue
+		}
+
+		items = append(items, ResultItem{
+			Title:     repo + "/" + shortSHA(sha) + " - " + firstLine(stringValue(commit.Message)),
+			TitleLink: stringValue(commit.URL),
+			Text:      "Pushed straight to " + branch + " by " + commitAuthor(commit),
+			Color:     "#ff1010",
+		})
+	}
+
+	if len(items) == 0 {
+		return
+	}
+
+	result := Result{
+		Text:  "[" + repo + "] :warning: " + pluralize(len(items), "A commit", "Commits") + " pushed to *" + branch + "* without a pull request by *" + userLogin(event.Sender) + "*",
+		Items: items,
+	}
+	notify(repo, SubscribeDirectPushes, result)
+}
+
+func commitAuthor(commit github.PushEventCommit) string {
+	if commit.Author == nil {
+		return "_Unknown_"
+	}
+	if commit.Author.Login != nil {
+		return "_" + *commit.Author.Login + "_"
+	}
+	return "_" + stringValue(commit.Author.Name) + "_"
+}
@@ -0,0 +1,223 @@
+package robots
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// VerifyGithubSignature checks the X-Hub-Signature of a webhook delivery against
+// the webhook secret from the github configuration. The SHA-256 signature is used
+// when GitHub sends one.
+func VerifyGithubSignature(header http.Header, body []byte) error {
+	if GithubConfig.WebhookSecret == "" {
+		return errors.New("no webhook secret is configured")
+	}
+
+	signature := header.Get("X-Hub-Signature-256")
+	prefix := "sha256="
+	newHash := sha256.New
+	if signature == "" {
+		signature = header.Get("X-Hub-Signature")
+		prefix = "sha1="
+		newHash = func() hash.Hash { return sha1.New() }
+	}
+	if !strings.HasPrefix(signature, prefix) {
+		return errors.New("missing signature")
+	}
+
+	mac := hmac.New(newHash, []byte(GithubConfig.WebhookSecret))
+	mac.Write(body)
+	expected := prefix + hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+// HandleGithubEvent decodes a webhook delivery of the given X-GitHub-Event type and
+// posts a notification to every channel subscribed to it.
+func HandleGithubEvent(eventType string, body []byte) error {
+	switch eventType {
+	case "issues":
+		event := new(github.IssuesEvent)
+		err := json.Unmarshal(body, event)
+		if err != nil {
+			return err
+		}
+		handleIssuesEvent(event)
+	case "pull_request":
+		event := new(github.PullRequestEvent)
+		err := json.Unmarshal(body, event)
+		if err != nil {
+			return err
+		}
+		handlePullRequestEvent(event)
+	case "push":
+		event := new(github.PushEvent)
+		err := json.Unmarshal(body, event)
+		if err != nil {
+			return err
+		}
+		handlePushEvent(event)
+	case "ping":
+		log.Println("GitHub says hello")
+	default:
+		log.Println("Ignoring GitHub event", eventType)
+	}
+	return nil
+}
+
+func notify(repo string, kind string, result Result) {
+	for _, channel := range SubscribedChannels(repo, kind) {
+		err := SendResult(channel, result)
+		if err != nil {
+			log.Printf("ERROR: Couldn't notify %s about %s: %s", channel, repo, err)
+		}
+	}
+}
+
+func handleIssuesEvent(event *github.IssuesEvent) {
+	if event.Repo == nil || event.Issue == nil || event.Action == nil {
+		return
+	}
+	action := *event.Action
+
+	var color string
+	switch action {
+	case "opened", "reopened":
+		color = "#36a64f"
+	case "closed":
+		color = "#A0A0A0"
+	case "assigned":
+		color = "#439FE0"
+	case "labeled", "unlabeled":
+		// Label changes are lane transitions and are reported separately.
+		handleLaneTransition(event)
+		return
+	default:
+		return
+	}
+
+	text := action + " by *" + userLogin(event.Sender) + "*"
+	if action == "assigned" && event.Assignee != nil {
+		text = "assigned to *" + *event.Assignee.Login + "* by *" + userLogin(event.Sender) + "*"
+	}
+
+	result := Result{
+		Text: "[" + *event.Repo.Name + "] Issue " + action,
+		Items: []ResultItem{{
+			Title:     "Issue #" + strconv.Itoa(*event.Issue.Number) + ", " + *event.Issue.Title,
+			TitleLink: *event.Issue.HTMLURL,
+			Text:      text,
+			Color:     color,
+		}},
+	}
+	notify(*event.Repo.Name, SubscribeIssues, result)
+}
+
+func handlePullRequestEvent(event *github.PullRequestEvent) {
+	if event.Repo == nil || event.PullRequest == nil || event.Action == nil {
+		return
+	}
+	action := *event.Action
+
+	var color string
+	switch action {
+	case "opened", "reopened":
+		color = "#36a64f"
+	case "closed":
+		color = "#A0A0A0"
+		if event.PullRequest.Merged != nil && *event.PullRequest.Merged {
+			action = "merged"
+			color = "#6f42c1"
+		}
+	default:
+		return
+	}
+
+	result := Result{
+		Text: "[" + *event.Repo.Name + "] Pull request " + action,
+		Items: []ResultItem{{
+			Title:     "PR #" + strconv.Itoa(*event.PullRequest.Number) + " - " + *event.PullRequest.Title,
+			TitleLink: *event.PullRequest.HTMLURL,
+			Text:      action + " by *" + userLogin(event.Sender) + "*",
+			Color:     color,
+		}},
+	}
+	notify(*event.Repo.Name, SubscribePRs, result)
+}
+
+func handlePushEvent(event *github.PushEvent) {
+	if event.Repo == nil || event.Ref == nil || len(event.Commits) == 0 {
+		return
+	}
+	branch := strings.TrimPrefix(*event.Ref, "refs/heads/")
+
+	var lines []string
+	for _, commit := range event.Commits {
+		lines = append(lines, "<"+stringValue(commit.URL)+"|"+shortSHA(commitID(commit))+"> "+firstLine(stringValue(commit.Message)))
+	}
+
+	result := Result{
+		Text: "[" + *event.Repo.Name + ":" + branch + "] " + strconv.Itoa(len(event.Commits)) + " new " + pluralize(len(event.Commits), "commit", "commits") + " by *" + userLogin(event.Sender) + "*",
+		Items: []ResultItem{{
+			Title:     *event.Repo.Name + ":" + branch,
+			TitleLink: stringValue(event.Compare),
+			Text:      strings.Join(lines, "\n"),
+			Color:     "#439FE0",
+		}},
+	}
+	notify(*event.Repo.Name, SubscribePushes, result)
+
+	handleDirectPush(event)
+}
+
+// Webhook deliveries name commits by "id" rather than "sha" and go-github only
+// knows the latter, but the commit's URL ends in its SHA either way.
+func commitID(commit github.PushEventCommit) string {
+	if commit.SHA != nil {
+		return *commit.SHA
+	}
+	if commit.URL != nil {
+		parts := strings.Split(*commit.URL, "/")
+		return parts[len(parts)-1]
+	}
+	return ""
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[0:7]
+	}
+	return sha
+}
+
+func firstLine(message string) string {
+	return strings.SplitN(message, "\n", 2)[0]
+}
+
+func userLogin(user *github.User) string {
+	if user == nil || user.Login == nil {
+		return "someone"
+	}
+	return *user.Login
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,81 @@
+package robots
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"testing"
+
+	. "github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func githubSignature(newHash func() hash.Hash, secret string, body string) string {
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestEvents(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	secret := "It's a Secret to Everybody"
+	body := `{"action":"opened","issue":{"number":1}}`
+
+	g.Describe("GitHub signatures", func() {
+		g.BeforeEach(func() {
+			GithubConfig.WebhookSecret = secret
+		})
+
+		g.AfterEach(func() {
+			GithubConfig.WebhookSecret = ""
+		})
+
+		g.It("Should accept a delivery signed with SHA-256 or SHA-1", func() {
+			header := http.Header{}
+			header.Set("X-Hub-Signature-256", "sha256="+githubSignature(sha256.New, secret, body))
+			Expect(VerifyGithubSignature(header, []byte(body))).To(Succeed())
+
+			header = http.Header{}
+			header.Set("X-Hub-Signature", "sha1="+githubSignature(sha1.New, secret, body))
+			Expect(VerifyGithubSignature(header, []byte(body))).To(Succeed())
+		})
+
+		g.It("Should prefer the SHA-256 signature when both are sent", func() {
+			header := http.Header{}
+			header.Set("X-Hub-Signature-256", "sha256="+githubSignature(sha256.New, "another secret", body))
+			header.Set("X-Hub-Signature", "sha1="+githubSignature(sha1.New, secret, body))
+			Expect(VerifyGithubSignature(header, []byte(body))).NotTo(Succeed())
+		})
+
+		g.It("Should refuse a tampered body or a different secret", func() {
+			header := http.Header{}
+			header.Set("X-Hub-Signature-256", "sha256="+githubSignature(sha256.New, secret, body))
+			Expect(VerifyGithubSignature(header, []byte(body+" "))).NotTo(Succeed())
+
+			header.Set("X-Hub-Signature-256", "sha256="+githubSignature(sha256.New, "another secret", body))
+			Expect(VerifyGithubSignature(header, []byte(body))).NotTo(Succeed())
+		})
+
+		g.It("Should refuse a delivery without a signature", func() {
+			Expect(VerifyGithubSignature(http.Header{}, []byte(body))).NotTo(Succeed())
+
+			header := http.Header{}
+			header.Set("X-Hub-Signature-256", githubSignature(sha256.New, secret, body))
+			Expect(VerifyGithubSignature(header, []byte(body))).NotTo(Succeed())
+		})
+
+		g.It("Should refuse everything without a webhook secret", func() {
+			GithubConfig.WebhookSecret = ""
+			header := http.Header{}
+			header.Set("X-Hub-Signature-256", "sha256="+githubSignature(sha256.New, "", body))
+			Expect(VerifyGithubSignature(header, []byte(body))).NotTo(Succeed())
+		})
+	})
+}
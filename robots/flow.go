@@ -0,0 +1,289 @@
+package robots
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RobotsAndPencils/marvin/githubservice"
+	"github.com/kelseyhightower/envconfig"
+)
+
+type FlowBot struct {
+}
+
+var FlowConfig = new(GithubConfiguration)
+
+// Loads the config file and registers the bot with the server for command /flow.
+func init() {
+	// Try to load the configuration from the environment and fall back to files in the filesystem
+	var c ConfigSpecification
+	err := envconfig.Process("github", &c)
+
+	if err != nil {
+		log.Println(err.Error())
+
+		// Fall back to reading from files if there is an error
+		loadFlowConfigFromFile()
+	} else {
+		err = json.Unmarshal([]byte(c.Config), FlowConfig)
+		if err != nil {
+			log.Println("error parsing config: ", err)
+			loadFlowConfigFromFile()
+		}
+	}
+	Flow := &FlowBot{}
+	RegisterRobot("flow", Flow)
+
+	snapshotTime := FlowConfig.FlowSnapshotTime
+	if snapshotTime == "" {
+		snapshotTime = "06:00"
+	}
+	RegisterJob("flow-snapshot", snapshotTime, RecordFlowSnapshots)
+}
+
+func loadFlowConfigFromFile() {
+	flag.Parse()
+	configFile := filepath.Join(*ConfigDirectory, "github.json")
+	if _, err := os.Stat(configFile); err == nil {
+		config, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			log.Printf("ERROR: Error opening github config: %s", err)
+			return
+		}
+		err = json.Unmarshal(config, FlowConfig)
+		if err != nil {
+			log.Printf("ERROR: Error parsing github config: %s", err)
+			return
+		}
+	} else {
+		log.Printf("WARNING: Could not find configuration file github.json in %s", *ConfigDirectory)
+	}
+}
+
+const (
+	flowBucket = "flow"
+
+	// About a year of daily snapshots is plenty for a trend and keeps the store small.
+	maxFlowSnapshots = 400
+
+	flowDateFormat = "2006-01-02"
+)
+
+// FlowSnapshot is the number of open issues in each lane of a repository on one day.
+type FlowSnapshot struct {
+	Date   string         `json:"date"`
+	Counts map[string]int `json:"counts"`
+}
+
+// RecordFlowSnapshots counts the issues in each lane of the configured repositories,
+// or of every repository pushed to in the last 30 days, and stores the counts as
+// the snapshot for the day.
+func RecordFlowSnapshots(now time.Time) {
+	service := NewGithubService(FlowConfig)
+
+	repos := FlowConfig.FlowRepos
+	if len(repos) == 0 {
+		var err error
+		repos, err = service.ActiveRepos(FlowConfig.Owner, 30)
+		if err != nil {
+			log.Printf("ERROR: Couldn't list repositories for flow snapshots: %s", err)
+			return
+		}
+	}
+
+	for _, repo := range repos {
+		_, err := recordFlowSnapshot(repo, now)
+		if err != nil {
+			log.Printf("ERROR: Couldn't take a flow snapshot of %s: %s", repo, err)
+		}
+	}
+}
+
+func recordFlowSnapshot(repo string, now time.Time) (FlowSnapshot, error) {
+	service := NewGithubService(FlowConfig)
+	counts, err := service.LaneCounts(FlowConfig.Owner, repo)
+	if err != nil {
+		return FlowSnapshot{}, err
+	}
+
+	snapshot := FlowSnapshot{Date: now.In(Location()).Format(flowDateFormat), Counts: counts}
+
+	var history []FlowSnapshot
+	for _, s := range FlowHistory(repo) {
+		if s.Date != snapshot.Date {
+			history = append(history, s)
+		}
+	}
+	history = append(history, snapshot)
+	if len(history) > maxFlowSnapshots {
+		history = history[len(history)-maxFlowSnapshots:]
+	}
+
+	return snapshot, DataStore().Put(flowBucket, strings.ToLower(repo), history)
+}
+
+// FlowHistory lists the snapshots of a repository, oldest first.
+func FlowHistory(repo string) []FlowSnapshot {
+	var history []FlowSnapshot
+	_, err := DataStore().Get(flowBucket, strings.ToLower(repo), &history)
+	if err != nil {
+		log.Printf("ERROR: Couldn't load flow snapshots for %s: %s", repo, err)
+	}
+	return history
+}
+
+// WeeklyFlowSnapshots picks the snapshot for each week going back from today, using
+// the latest snapshot on or before each day. Weeks before the first snapshot are left out.
+func WeeklyFlowSnapshots(history []FlowSnapshot, today time.Time, weeks int) []FlowSnapshot {
+	var weekly []FlowSnapshot
+	for week := weeks; week >= 0; week-- {
+		day := today.AddDate(0, 0, -7*week).Format(flowDateFormat)
+
+		var found *FlowSnapshot
+		for i := range history {
+			if history[i].Date <= day {
+				found = &history[i]
+			}
+		}
+		if found != nil && (len(weekly) == 0 || weekly[len(weekly)-1].Date != found.Date) {
+			weekly = append(weekly, *found)
+		}
+	}
+	return weekly
+}
+
+func (r FlowBot) parsePayload(p *Payload) (repo string, weeks int, err error) {
+	args, flags := ParseArguments(p.Text)
+	if len(args) != 1 {
+		return "", 0, errors.New("Usage: /flow repo [--weeks 6]")
+	}
+
+	weeks = 6
+	if value, ok := flags["weeks"]; ok {
+		weeks, err = strconv.Atoi(value)
+		if err != nil || weeks <= 0 {
+			return "", 0, errors.New("I need a number of weeks, like --weeks 6")
+		}
+	}
+	return args[0], weeks, nil
+}
+
+// All Robots must implement a Run command to be executed when the registered command is received.
+func (r FlowBot) Run(p *Payload) string {
+	repo, weeks, err := r.parsePayload(p)
+	if err != nil {
+		return err.Error()
+	}
+
+	// If you (optionally) want to do some asynchronous work (like sending API calls to slack)
+	// you can put it in a go routine like this
+	go r.DeferredAction(p)
+	// The string returned here will be shown only to the user who executed the command
+	// and will show up as a message from slackbot.
+
+	return "Looking at the flow of " + repo + " over the last " + strconv.Itoa(weeks) + " weeks..."
+}
+
+func (r FlowBot) DeferredAction(p *Payload) {
+
+	repo, weeks, _ := r.parsePayload(p)
+	now := time.Now().In(Location())
+
+	var items []ResultItem
+	text := "Flow of *" + repo + "* over the last " + strconv.Itoa(weeks) + " weeks"
+
+	history := FlowHistory(repo)
+	if len(history) == 0 {
+		// Start the history now so the next /flow has something to compare with.
+		_, err := recordFlowSnapshot(repo, now)
+		if err != nil {
+			items = append(items, ResultItem{
+				Text:  "Error: " + err.Error(),
+				Color: "#ff0000",
+			})
+		}
+		history = FlowHistory(repo)
+		text += "\nThere were no snapshots of " + repo + " yet, so this is where its history starts."
+	}
+
+	snapshots := WeeklyFlowSnapshots(history, now, weeks)
+	if len(snapshots) > 0 {
+		var dates []string
+		for _, snapshot := range snapshots {
+			date, _ := time.Parse(flowDateFormat, snapshot.Date)
+			dates = append(dates, date.Format("Jan 2"))
+		}
+		text += "\n" + strings.Join(dates, " → ")
+		items = append(items, BuildFlowItems(snapshots, BoardLanes())...)
+	}
+
+	// Let's describe the response with the Result struct defined in result.go and send it as an
+	// IncomingWebhook message to slack that can be seen by everyone in the room. You can
+	// read the Slack API Docs (https://api.slack.com/) to know which fields are required, etc.
+	// You can also see what data is available from the command structure in definitions.go
+	result := Result{
+		Text:    text,
+		Items:   items,
+		Actions: []ResultAction{RefreshButton(p.Robot, p.Text)},
+	}
+
+	SendResult(p.ChannelID, result)
+
+	// The chart shows every daily snapshot in the period, not just the weekly ones.
+	start := now.AddDate(0, 0, -7*weeks).Format(flowDateFormat)
+	var daily []FlowSnapshot
+	for _, snapshot := range history {
+		if snapshot.Date >= start {
+			daily = append(daily, snapshot)
+		}
+	}
+	if len(daily) > 1 {
+		SendChart(p.ChannelID, repo+"-flow.png", FlowChart(repo, daily, BoardLanes()))
+	}
+}
+
+// BuildFlowItems shows how many issues were in each lane week by week, in board
+// order. Lanes that keep filling up are coloured so a bottleneck stands out.
+func BuildFlowItems(snapshots []FlowSnapshot, lanes []githubservice.Lane) []ResultItem {
+	var items []ResultItem
+	for _, lane := range lanes {
+		var counts []string
+		for _, snapshot := range snapshots {
+			counts = append(counts, strconv.Itoa(snapshot.Counts[lane.Name]))
+		}
+
+		first := snapshots[0].Counts[lane.Name]
+		last := snapshots[len(snapshots)-1].Counts[lane.Name]
+		items = append(items, ResultItem{
+			Title: lane.Name,
+			Text:  strings.Join(counts, " → "),
+			Color: colorForFlowTrend(lane, first, last),
+		})
+	}
+	return items
+}
+
+func colorForFlowTrend(lane githubservice.Lane, first int, last int) string {
+	if lane.IsCatchAll() || last <= first {
+		return "#A0A0A0"
+	} else if last >= first*3/2+2 {
+		return "#FF1010"
+	} else {
+		return "#FFD334"
+	}
+}
+
+func (r FlowBot) Description() (description string) {
+	// In addition to a Run method, each Robot must implement a Description method which
+	// is just a simple string describing what the Robot does. This is used in the included
+	// /c command which gives users a list of commands and descriptions
+	return "This is a description for FlowBot which will be displayed on /c"
+}
@@ -0,0 +1,55 @@
+package robots
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// GithubConfig is used by everything that is not a slash command robot, such as
+// interactive actions, so they don't depend on any one robot's configuration.
+var GithubConfig = new(GithubConfiguration)
+
+// Loads the github config used outside of the robots.
+func init() {
+	// Try to load the configuration from the environment and fall back to files in the filesystem
+	var c ConfigSpecification
+	err := envconfig.Process("github", &c)
+
+	if err != nil {
+		log.Println(err.Error())
+
+		// Fall back to reading from files if there is an error
+		loadGithubConfigFromFile()
+	} else {
+		err = json.Unmarshal([]byte(c.Config), GithubConfig)
+		if err != nil {
+			log.Println("error parsing config: ", err)
+			loadGithubConfigFromFile()
+		}
+	}
+}
+
+func loadGithubConfigFromFile() {
+	flag.Parse()
+	configFile := filepath.Join(*ConfigDirectory, "github.json")
+	if _, err := os.Stat(configFile); err == nil {
+		config, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			log.Printf("ERROR: Error opening github config: %s", err)
+			return
+		}
+		err = json.Unmarshal(config, GithubConfig)
+		if err != nil {
+			log.Printf("ERROR: Error parsing github config: %s", err)
+			return
+		}
+	} else {
+		log.Printf("WARNING: Could not find configuration file github.json in %s", *ConfigDirectory)
+	}
+}
@@ -0,0 +1,92 @@
+package robots
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/RobotsAndPencils/marvin/githubservice"
+)
+
+// GithubLogin finds the GitHub login of a Slack user. Identities in the configuration
+// map either a Slack user id or a Slack user name to a GitHub login. Anyone can rename
+// themselves in Slack, so this is only for looking things up; changes go through
+// LinkedGithubLogin.
+func GithubLogin(userID string, userName string) (string, error) {
+	if login, ok := Config.Identities[userID]; ok {
+		return login, nil
+	}
+	if login, ok := Config.Identities[userName]; ok {
+		return login, nil
+	}
+	return "", errors.New("no GitHub login is linked to Slack user " + userName + ", ask an admin to add it to the identities in config.json")
+}
+
+// LinkedGithubLogin finds the GitHub login linked to a Slack user id, ignoring
+// identities keyed by user name.
+func LinkedGithubLogin(userID string) (string, error) {
+	if login, ok := Config.Identities[userID]; ok && isSlackUserID(userID) {
+		return login, nil
+	}
+	return "", errors.New("no GitHub login is linked to your Slack user id " + userID + ", ask an admin to add it to the identities in config.json")
+}
+
+// SlackMention mentions the Slack user linked to a GitHub login, or is empty when
+// nobody is. Identities keyed by Slack user id notify the user; those keyed by name
+// can only show it.
+func SlackMention(login string) string {
+	for slackUser, githubLogin := range Config.Identities {
+		if !strings.EqualFold(githubLogin, login) {
+			continue
+		}
+		if isSlackUserID(slackUser) {
+			return "<@" + slackUser + ">"
+		}
+		return "@" + slackUser
+	}
+	return ""
+}
+
+var slackUserIDPattern = regexp.MustCompile(`^[UW][A-Z0-9]{8,}$`)
+
+// isSlackUserID recognizes ids like U024BE7LH, as opposed to user names.
+func isSlackUserID(s string) bool {
+	return slackUserIDPattern.MatchString(s)
+}
+
+// ResolveLogin turns "me" into the GitHub login of the Slack user and leaves any
+// other login alone.
+func ResolveLogin(login string, p *Payload) (string, error) {
+	if strings.ToLower(login) == "me" {
+		return GithubLogin(p.UserID, p.UserName)
+	}
+	return strings.TrimPrefix(login, "@"), nil
+}
+
+// ResolveAssignee is ResolveLogin for changes, where "me" only counts when the Slack
+// user id is linked to a GitHub login.
+func ResolveAssignee(login string, p *Payload) (string, error) {
+	if strings.ToLower(login) == "me" {
+		return LinkedGithubLogin(p.UserID)
+	}
+	return strings.TrimPrefix(login, "@"), nil
+}
+
+// AuthorizeChange makes sure the Slack user behind p is a collaborator on repo
+// before Marvin changes anything in it, and returns their GitHub login so the
+// change can be credited to them.
+func AuthorizeChange(service *githubservice.GithubService, owner string, repo string, p *Payload) (string, error) {
+	login, err := LinkedGithubLogin(p.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	isCollaborator, err := service.IsCollaborator(owner, repo, login)
+	if err != nil {
+		return "", err
+	}
+	if !isCollaborator {
+		return "", errors.New(login + " is not a collaborator on " + repo + ", so Marvin won't change it for them")
+	}
+	return login, nil
+}
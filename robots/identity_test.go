@@ -0,0 +1,55 @@
+package robots
+
+import (
+	"testing"
+
+	. "github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestIdentity(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("GitHub logins", func() {
+		g.BeforeEach(func() {
+			Config.Identities = map[string]string{"U024BE7LH": "alice", "bob": "bob-gh"}
+		})
+
+		g.AfterEach(func() {
+			Config.Identities = nil
+		})
+
+		g.It("Should look logins up by Slack user id or name", func() {
+			Expect(GithubLogin("U024BE7LH", "whoever")).To(Equal("alice"))
+			Expect(GithubLogin("U999BE7LH", "bob")).To(Equal("bob-gh"))
+		})
+
+		g.It("Should only link Slack user ids for changes", func() {
+			Expect(LinkedGithubLogin("U024BE7LH")).To(Equal("alice"))
+
+			_, err := LinkedGithubLogin("bob")
+			Expect(err).To(HaveOccurred())
+
+			_, err = ResolveAssignee("me", &Payload{UserID: "U999BE7LH", UserName: "bob"})
+			Expect(err).To(HaveOccurred())
+			Expect(ResolveAssignee("@carol", &Payload{UserID: "U999BE7LH"})).To(Equal("carol"))
+		})
+	})
+
+	g.Describe("Slack user ids", func() {
+		g.It("Should recognize user ids", func() {
+			for _, id := range []string{"U024BE7LH", "W012A3CDE", "U01234567ABCD"} {
+				Expect(isSlackUserID(id)).To(BeTrue())
+			}
+		})
+
+		g.It("Should not mistake user names for ids", func() {
+			for _, name := range []string{"", "U", "UX", "WILL", "USER", "U024be7lh", "U024BE7-H", "B024BE7LH", "bob"} {
+				Expect(isSlackUserID(name)).To(BeFalse())
+			}
+		})
+	})
+}
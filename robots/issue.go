@@ -0,0 +1,179 @@
+package robots
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/RobotsAndPencils/marvin/githubservice"
+	"github.com/google/go-github/github"
+	"github.com/kelseyhightower/envconfig"
+)
+
+type IssueBot struct {
+}
+
+var IssueConfig = new(GithubConfiguration)
+
+// Loads the config file and registers the bot with the server for command /issue.
+func init() {
+	// Try to load the configuration from the environment and fall back to files in the filesystem
+	var c ConfigSpecification
+	err := envconfig.Process("github", &c)
+
+	if err != nil {
+		log.Println(err.Error())
+
+		// Fall back to reading from files if there is an error
+		loadIssueConfigFromFile()
+	} else {
+		err = json.Unmarshal([]byte(c.Config), IssueConfig)
+		if err != nil {
+			log.Println("error parsing config: ", err)
+			loadIssueConfigFromFile()
+		}
+	}
+	Issue := &IssueBot{}
+	RegisterRobot("issue", Issue)
+}
+
+func loadIssueConfigFromFile() {
+	flag.Parse()
+	configFile := filepath.Join(*ConfigDirectory, "github.json")
+	if _, err := os.Stat(configFile); err == nil {
+		config, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			log.Printf("ERROR: Error opening github config: %s", err)
+			return
+		}
+		err = json.Unmarshal(config, IssueConfig)
+		if err != nil {
+			log.Printf("ERROR: Error parsing github config: %s", err)
+			return
+		}
+	} else {
+		log.Printf("WARNING: Could not find configuration file github.json in %s", *ConfigDirectory)
+	}
+}
+
+func (r IssueBot) parsePayload(p *Payload) (action string, reference string, argument string) {
+	output := strings.SplitN(strings.TrimSpace(p.Text), " ", 3)
+	if len(output) < 2 {
+		return "", "", ""
+	}
+	if len(output) == 3 {
+		argument = strings.TrimSpace(output[2])
+	}
+	return strings.ToLower(output[0]), output[1], argument
+}
+
+// All Robots must implement a Run command to be executed when the registered command is received.
+func (r IssueBot) Run(p *Payload) string {
+	action, reference, argument := r.parsePayload(p)
+	_, _, err := ParseIssueReference(reference)
+
+	usage := "Usage: /issue close repo#number, /issue reopen repo#number, /issue assign repo#number login|me or /issue comment repo#number text"
+	if err != nil {
+		return usage
+	}
+	switch action {
+	case "close", "reopen":
+	case "assign", "comment":
+		if argument == "" {
+			return usage
+		}
+	default:
+		return usage
+	}
+
+	// If you (optionally) want to do some asynchronous work (like sending API calls to slack)
+	// you can put it in a go routine like this
+	go r.DeferredAction(p)
+	// The string returned here will be shown only to the user who executed the command
+	// and will show up as a message from slackbot.
+
+	return "Updating " + reference + "..."
+}
+
+func (r IssueBot) DeferredAction(p *Payload) {
+
+	action, reference, argument := r.parsePayload(p)
+	repo, number, _ := ParseIssueReference(reference)
+
+	service := NewGithubService(IssueConfig)
+	issue, text, err := r.change(service, p, action, repo, number, argument)
+	if err != nil {
+		text = "Couldn't " + action + " " + reference
+	}
+
+	// Let's describe the response with the Result struct defined in result.go and send it as an
+	// IncomingWebhook message to slack that can be seen by everyone in the room. You can
+	// read the Slack API Docs (https://api.slack.com/) to know which fields are required, etc.
+	// You can also see what data is available from the command structure in definitions.go
+	result := Result{
+		Text:  text,
+		Items: BuildIssueItems(issuesOrNone(issue), err),
+	}
+
+	SendResult(p.ChannelID, result)
+}
+
+func (r IssueBot) change(service *githubservice.GithubService, p *Payload, action string, repo string, number int, argument string) (*github.Issue, string, error) {
+	owner := IssueConfig.Owner
+	reference := repo + "#" + strconv.Itoa(number)
+
+	login, err := AuthorizeChange(service, owner, repo, p)
+	if err != nil {
+		return nil, "", err
+	}
+	credit := "_" + login + " via Slack_"
+
+	switch action {
+	case "close":
+		// Change the state first so a failure doesn't leave a comment claiming it happened.
+		issue, err := service.CloseIssue(owner, repo, number)
+		if err != nil {
+			return nil, "", err
+		}
+		creditChange(service, owner, repo, number, "Closed by "+credit)
+		return issue, "*" + login + "* closed " + reference, nil
+	case "reopen":
+		issue, err := service.ReopenIssue(owner, repo, number)
+		if err != nil {
+			return nil, "", err
+		}
+		creditChange(service, owner, repo, number, "Reopened by "+credit)
+		return issue, "*" + login + "* reopened " + reference, nil
+	case "assign":
+		assignee, err := ResolveAssignee(argument, p)
+		if err != nil {
+			return nil, "", err
+		}
+		issue, err := service.AssignIssue(owner, repo, number, assignee)
+		return issue, "*" + login + "* assigned " + reference + " to *" + assignee + "*", err
+	default:
+		issue, err := service.CommentOnIssue(owner, repo, number, argument+"\n\n— "+credit)
+		return issue, "*" + login + "* commented on " + reference + ": " + argument, err
+	}
+}
+
+// creditChange comments on an issue with who changed it from Slack. The change has
+// already happened by then, so a failed comment is only logged.
+func creditChange(service *githubservice.GithubService, owner string, repo string, number int, comment string) {
+	_, err := service.CommentOnIssue(owner, repo, number, comment)
+	if err != nil {
+		log.Printf("ERROR: Couldn't comment on %s#%d: %s", repo, number, err)
+	}
+}
+
+func (r IssueBot) Description() (description string) {
+	// In addition to a Run method, each Robot must implement a Description method which
+	// is just a simple string describing what the Robot does. This is used in the included
+	// /c command which gives users a list of commands and descriptions
+	return "This is a description for IssueBot which will be displayed on /c"
+}
@@ -0,0 +1,73 @@
+package robots
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/RobotsAndPencils/marvin/scheduler"
+)
+
+// Job is work a robot wants done every day at a set time, such as taking a snapshot.
+type Job struct {
+	Name string
+	At   string
+	Run  func(now time.Time)
+}
+
+var Jobs []Job
+
+// RegisterJob adds a daily job, to run at a time of day written as 15:04 in the
+// configured time zone. Jobs only run once StartScheduler is called.
+func RegisterJob(name string, at string, run func(now time.Time)) {
+	log.Printf("Scheduled: %s at %s", name, at)
+	Jobs = append(Jobs, Job{Name: name, At: at, Run: run})
+}
+
+var jobScheduler *scheduler.Scheduler
+var jobSchedulerOnce sync.Once
+
+func jobs() *scheduler.Scheduler {
+	jobSchedulerOnce.Do(func() {
+		jobScheduler = scheduler.New(DataStore(), Location())
+	})
+	return jobScheduler
+}
+
+// StartScheduler runs the registered jobs and the digests users asked for every
+// day from now on.
+func StartScheduler() {
+	for _, job := range Jobs {
+		err := jobs().Daily(job.Name, job.At, job.Run)
+		if err != nil {
+			log.Printf("ERROR: %s", err)
+		}
+	}
+	ScheduleDigests()
+	jobs().Start()
+}
+
+// ScheduleJob adds or reschedules a daily job while Marvin is running, such as a
+// digest someone just asked for.
+func ScheduleJob(name string, at string, run func(now time.Time)) error {
+	return jobs().Daily(name, at, run)
+}
+
+// UnscheduleJob stops a job added with ScheduleJob.
+func UnscheduleJob(name string) {
+	jobs().Remove(name)
+}
+
+// Location is the configured time zone, used for anything that depends on the time
+// of day. It defaults to the time zone of the server.
+func Location() *time.Location {
+	if Config.TimeZone == "" {
+		return time.Local
+	}
+	location, err := time.LoadLocation(Config.TimeZone)
+	if err != nil {
+		log.Printf("ERROR: Unknown time zone %s: %s", Config.TimeZone, err)
+		return time.Local
+	}
+	return location
+}
@@ -0,0 +1,73 @@
+package robots
+
+import (
+	"strings"
+)
+
+type MarvinBot struct {
+}
+
+// Registers the bot with the server for command /marvin.
+func init() {
+	Marvin := &MarvinBot{}
+	RegisterRobot("marvin", Marvin)
+}
+
+const marvinUsage = "Usage: /marvin digest [on 9:00|off|now]"
+
+// All Robots must implement a Run command to be executed when the registered command is received.
+func (r MarvinBot) Run(p *Payload) string {
+	words := strings.Fields(p.Text)
+	if len(words) == 0 || strings.ToLower(words[0]) != "digest" {
+		return marvinUsage
+	}
+	return r.digest(p, words[1:])
+}
+
+func (r MarvinBot) digest(p *Payload, words []string) string {
+	if len(words) == 0 {
+		digest, err := LoadDigest(p.UserID)
+		if err != nil {
+			return "Couldn't find your digest: " + err.Error()
+		}
+		if digest == nil {
+			return "You don't get a digest. Try /marvin digest on 9:00"
+		}
+		return "You get a digest every workday at " + digest.At + "."
+	}
+
+	switch strings.ToLower(words[0]) {
+	case "on":
+		if len(words) != 2 {
+			return marvinUsage
+		}
+		digest, err := EnableDigest(p, words[1])
+		if err != nil {
+			return "Couldn't set up your digest: " + err.Error()
+		}
+		return "You'll get a direct message with your digest every workday at " + digest.At + "."
+	case "off":
+		err := DisableDigest(p.UserID)
+		if err != nil {
+			return "Couldn't stop your digest: " + err.Error()
+		}
+		return "You won't get a digest anymore."
+	case "now":
+		digest, err := LoadDigest(p.UserID)
+		if err != nil || digest == nil {
+			return "You don't get a digest yet. Try /marvin digest on 9:00"
+		}
+		// If you (optionally) want to do some asynchronous work (like sending API calls to slack)
+		// you can put it in a go routine like this
+		go SendDigest(p.UserID)
+		return "Putting your digest together..."
+	}
+	return marvinUsage
+}
+
+func (r MarvinBot) Description() (description string) {
+	// In addition to a Run method, each Robot must implement a Description method which
+	// is just a simple string describing what the Robot does. This is used in the included
+	// /c command which gives users a list of commands and descriptions
+	return "This is a description for MarvinBot which will be displayed on /c"
+}
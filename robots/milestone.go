@@ -0,0 +1,297 @@
+package robots
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RobotsAndPencils/marvin/chart"
+	"github.com/RobotsAndPencils/marvin/githubservice"
+	"github.com/google/go-github/github"
+	"github.com/kelseyhightower/envconfig"
+)
+
+type MilestoneBot struct {
+}
+
+var MilestoneConfig = new(GithubConfiguration)
+
+// Loads the config file and registers the bot with the server for command /milestone.
+func init() {
+	// Try to load the configuration from the environment and fall back to files in the filesystem
+	var c ConfigSpecification
+	err := envconfig.Process("github", &c)
+
+	if err != nil {
+		log.Println(err.Error())
+
+		// Fall back to reading from files if there is an error
+		loadMilestoneConfigFromFile()
+	} else {
+		err = json.Unmarshal([]byte(c.Config), MilestoneConfig)
+		if err != nil {
+			log.Println("error parsing config: ", err)
+			loadMilestoneConfigFromFile()
+		}
+	}
+	Milestone := &MilestoneBot{}
+	RegisterRobot("milestone", Milestone)
+}
+
+func loadMilestoneConfigFromFile() {
+	flag.Parse()
+	configFile := filepath.Join(*ConfigDirectory, "github.json")
+	if _, err := os.Stat(configFile); err == nil {
+		config, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			log.Printf("ERROR: Error opening github config: %s", err)
+			return
+		}
+		err = json.Unmarshal(config, MilestoneConfig)
+		if err != nil {
+			log.Printf("ERROR: Error parsing github config: %s", err)
+			return
+		}
+	} else {
+		log.Printf("WARNING: Could not find configuration file github.json in %s", *ConfigDirectory)
+	}
+}
+
+// The burndown chart starts when the milestone was created, but no more than this
+// many days ago so old milestones stay readable.
+const maxBurndownDays = 60
+
+func (r MilestoneBot) parsePayload(p *Payload) (repo string, name string) {
+	output := strings.SplitN(strings.TrimSpace(p.Text), " ", 2)
+	if len(output) < 2 {
+		return output[0], ""
+	}
+	return output[0], strings.TrimSpace(output[1])
+}
+
+// All Robots must implement a Run command to be executed when the registered command is received.
+func (r MilestoneBot) Run(p *Payload) string {
+	repo, name := r.parsePayload(p)
+	if repo == "" {
+		return "Usage: /milestone repo [name]"
+	}
+
+	// If you (optionally) want to do some asynchronous work (like sending API calls to slack)
+	// you can put it in a go routine like this
+	go r.DeferredAction(p)
+	// The string returned here will be shown only to the user who executed the command
+	// and will show up as a message from slackbot.
+
+	if name == "" {
+		return "Finding the open milestones of " + repo + "..."
+	}
+	return "Checking on milestone " + name + " in " + repo + "..."
+}
+
+func (r MilestoneBot) DeferredAction(p *Payload) {
+
+	repo, name := r.parsePayload(p)
+	service := NewGithubService(MilestoneConfig)
+	now := time.Now().In(Location())
+
+	var result Result
+	var progress *githubservice.MilestoneProgress
+
+	if name == "" {
+		milestones, err := service.OpenMilestones(MilestoneConfig.Owner, repo)
+		result = Result{
+			Text:  "Open milestones in *" + repo + "*",
+			Items: BuildMilestoneItems(milestones, err, now),
+		}
+	} else {
+		var err error
+		progress, err = service.Milestone(MilestoneConfig.Owner, repo, name)
+		result = Result{
+			Text:  "Milestone *" + name + "* in *" + repo + "*",
+			Items: BuildMilestoneProgressItems(progress, err, BoardLanes(), now),
+		}
+	}
+	result.Actions = []ResultAction{RefreshButton(p.Robot, p.Text)}
+
+	// Let's describe the response with the Result struct defined in result.go and send it as an
+	// IncomingWebhook message to slack that can be seen by everyone in the room. You can
+	// read the Slack API Docs (https://api.slack.com/) to know which fields are required, etc.
+	// You can also see what data is available from the command structure in definitions.go
+	SendResult(p.ChannelID, result)
+
+	if progress != nil && len(progress.Issues) > 0 {
+		SendChart(p.ChannelID, repo+"-burndown.png", BurndownChart(*progress, now))
+	}
+}
+
+func BuildMilestoneItems(milestones []github.Milestone, err error, now time.Time) []ResultItem {
+	var items []ResultItem
+
+	if err != nil {
+		return append(items, ResultItem{
+			Text:  "Error: " + err.Error(),
+			Color: "#ff0000",
+		})
+	}
+	if len(milestones) == 0 {
+		return append(items, ResultItem{
+			Text:  "There are no open milestones.",
+			Color: "#A0A0A0",
+		})
+	}
+
+	for _, milestone := range milestones {
+		open, closed := intValue(milestone.OpenIssues), intValue(milestone.ClosedIssues)
+		items = append(items, ResultItem{
+			Title:     *milestone.Title,
+			TitleLink: stringValue(milestone.HTMLURL),
+			Text:      strconv.Itoa(open) + " open, " + strconv.Itoa(closed) + " closed, " + describeDueDate(milestone.DueOn, now),
+			Color:     colorForMilestone(milestone.DueOn, nil, open, now),
+		})
+	}
+	return items
+}
+
+// BuildMilestoneProgressItems shows where a milestone stands: its issue counts, how
+// long is left, when it will be done at the recent closing rate, and where its open
+// issues are on the board.
+func BuildMilestoneProgressItems(progress *githubservice.MilestoneProgress, err error, lanes []githubservice.Lane, now time.Time) []ResultItem {
+	var items []ResultItem
+
+	if err != nil {
+		return append(items, ResultItem{
+			Text:  "Error: " + err.Error(),
+			Color: "#ff0000",
+		})
+	}
+
+	milestone := progress.Milestone
+	open := len(progress.OpenIssues())
+	closed := len(progress.Issues) - open
+
+	projection := "Nothing was closed in the last two weeks"
+	projected, ok := progress.ProjectedCompletion(now)
+	if open == 0 {
+		projection = "Done"
+	} else if ok {
+		projection = projected.Format("Mon Jan 2") + " at " + strconv.FormatFloat(progress.ClosingRate(now)*7, 'f', 1, 64) + " issues a week"
+	}
+
+	due := "No due date"
+	if milestone.DueOn != nil {
+		due = milestone.DueOn.In(now.Location()).Format("Mon Jan 2") + ", " + describeDueDate(milestone.DueOn, now)
+	}
+
+	var projectedPtr *time.Time
+	if ok {
+		projectedPtr = &projected
+	}
+	items = append(items, ResultItem{
+		Title:     *milestone.Title,
+		TitleLink: stringValue(milestone.HTMLURL),
+		Text:      stringValue(milestone.Description),
+		Color:     colorForMilestone(milestone.DueOn, projectedPtr, open, now),
+		Fields: []ResultField{
+			{Title: "Open", Value: strconv.Itoa(open), Short: true},
+			{Title: "Closed", Value: strconv.Itoa(closed), Short: true},
+			{Title: "Due", Value: due, Short: true},
+			{Title: "Projected completion", Value: projection, Short: true},
+		},
+	})
+
+	counts := progress.LaneCounts(lanes)
+	var fields []ResultField
+	for _, lane := range lanes {
+		if counts[lane.Name] > 0 {
+			fields = append(fields, ResultField{Title: lane.Name, Value: strconv.Itoa(counts[lane.Name]), Short: true})
+		}
+	}
+	if len(fields) > 0 {
+		items = append(items, ResultItem{
+			Title:  "Open issues by lane",
+			Color:  "#439FE0",
+			Fields: fields,
+		})
+	}
+	return items
+}
+
+// describeDueDate says how many days are left until the due date, or how late it is.
+func describeDueDate(due *time.Time, now time.Time) string {
+	if due == nil {
+		return "no due date"
+	}
+	days := int(math.Ceil(due.Sub(now).Hours() / 24))
+	if days < 0 {
+		return strconv.Itoa(-days) + " " + pluralize(-days, "day", "days") + " overdue"
+	} else if days == 0 {
+		return "due today"
+	}
+	return strconv.Itoa(days) + " " + pluralize(days, "day", "days") + " left"
+}
+
+func colorForMilestone(due *time.Time, projected *time.Time, open int, now time.Time) string {
+	if open == 0 {
+		return "#36a64f"
+	} else if due == nil {
+		return "#A0A0A0"
+	} else if due.Before(now) {
+		return "#ff1010"
+	} else if projected == nil || projected.After(*due) {
+		return "#FF7222"
+	} else {
+		return "#36a64f"
+	}
+}
+
+// BurndownChart draws the open issues of a milestone day by day, with an ideal line
+// reaching zero on the due date when the milestone has one.
+func BurndownChart(progress githubservice.MilestoneProgress, now time.Time) chart.Chart {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	start := today.AddDate(0, 0, -maxBurndownDays)
+	if created := progress.Milestone.CreatedAt; created != nil && created.After(start) {
+		start = time.Date(created.Year(), created.Month(), created.Day(), 0, 0, 0, 0, now.Location())
+	}
+
+	days, remaining := progress.Burndown(start, today)
+
+	var labels []string
+	for _, day := range days {
+		labels = append(labels, day.Format("Jan 2"))
+	}
+
+	var ideal []float64
+	if due := progress.Milestone.DueOn; due != nil && due.After(start) {
+		total := due.Sub(start).Hours()
+		for _, day := range days {
+			ideal = append(ideal, math.Max(0, remaining[0]*(1-day.Sub(start).Hours()/total)))
+		}
+	}
+
+	c := chart.Burndown("Burndown of "+*progress.Milestone.Title, labels, remaining, ideal)
+	if ideal == nil {
+		c.Series = c.Series[:1]
+	}
+	return c
+}
+
+func intValue(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+func (r MilestoneBot) Description() (description string) {
+	// In addition to a Run method, each Robot must implement a Description method which
+	// is just a simple string describing what the Robot does. This is used in the included
+	// /c command which gives users a list of commands and descriptions
+	return "This is a description for MilestoneBot which will be displayed on /c"
+}
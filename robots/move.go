@@ -0,0 +1,163 @@
+package robots
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/RobotsAndPencils/marvin/githubservice"
+	"github.com/google/go-github/github"
+	"github.com/kelseyhightower/envconfig"
+)
+
+type MoveBot struct {
+}
+
+const MoveToLaneActionID = "move_to_lane"
+
+var MoveConfig = new(GithubConfiguration)
+
+// Loads the config file and registers the bot with the server for command /move.
+func init() {
+	// Try to load the configuration from the environment and fall back to files in the filesystem
+	var c ConfigSpecification
+	err := envconfig.Process("github", &c)
+
+	if err != nil {
+		log.Println(err.Error())
+
+		// Fall back to reading from files if there is an error
+		loadMoveConfigFromFile()
+	} else {
+		err = json.Unmarshal([]byte(c.Config), MoveConfig)
+		if err != nil {
+			log.Println("error parsing config: ", err)
+			loadMoveConfigFromFile()
+		}
+	}
+	Move := &MoveBot{}
+	RegisterRobot("move", Move)
+	RegisterAction(MoveToLaneActionID, &MoveToLaneAction{})
+}
+
+func loadMoveConfigFromFile() {
+	flag.Parse()
+	configFile := filepath.Join(*ConfigDirectory, "github.json")
+	if _, err := os.Stat(configFile); err == nil {
+		config, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			log.Printf("ERROR: Error opening github config: %s", err)
+			return
+		}
+		err = json.Unmarshal(config, MoveConfig)
+		if err != nil {
+			log.Printf("ERROR: Error parsing github config: %s", err)
+			return
+		}
+	} else {
+		log.Printf("WARNING: Could not find configuration file github.json in %s", *ConfigDirectory)
+	}
+}
+
+func (r MoveBot) parsePayload(p *Payload) (reference string, lane string) {
+	output := strings.SplitN(strings.TrimSpace(p.Text), " ", 2)
+	if len(output) < 2 {
+		return output[0], ""
+	}
+	return output[0], strings.TrimSpace(output[1])
+}
+
+// All Robots must implement a Run command to be executed when the registered command is received.
+func (r MoveBot) Run(p *Payload) string {
+	reference, lane := r.parsePayload(p)
+	_, _, err := ParseIssueReference(reference)
+	if err != nil || lane == "" {
+		return "Usage: /move repo#number lane"
+	}
+
+	// If you (optionally) want to do some asynchronous work (like sending API calls to slack)
+	// you can put it in a go routine like this
+	go r.DeferredAction(p)
+	// The string returned here will be shown only to the user who executed the command
+	// and will show up as a message from slackbot.
+
+	return "Moving " + reference + " to " + lane + "..."
+}
+
+func (r MoveBot) DeferredAction(p *Payload) {
+
+	reference, lane := r.parsePayload(p)
+	repo, number, _ := ParseIssueReference(reference)
+
+	service := NewGithubService(MoveConfig)
+	login, err := AuthorizeChange(service, MoveConfig.Owner, repo, p)
+
+	var issue *github.Issue
+	var from, to *githubservice.Lane
+	if err == nil {
+		issue, from, to, err = service.MoveIssue(MoveConfig.Owner, repo, number, lane)
+	}
+
+	var text string = "Couldn't move " + reference
+	if err == nil {
+		text = "*" + login + "* moved " + reference + " from *" + laneName(from) + "* to *" + laneName(to) + "*"
+	}
+
+	// Let's describe the response with the Result struct defined in result.go and send it as an
+	// IncomingWebhook message to slack that can be seen by everyone in the room. You can
+	// read the Slack API Docs (https://api.slack.com/) to know which fields are required, etc.
+	// You can also see what data is available from the command structure in definitions.go
+	result := Result{
+		Text:  text,
+		Items: BuildIssueItems(issuesOrNone(issue), err),
+	}
+
+	SendResult(p.ChannelID, result)
+}
+
+func (r MoveBot) Description() (description string) {
+	// In addition to a Run method, each Robot must implement a Description method which
+	// is just a simple string describing what the Robot does. This is used in the included
+	// /c command which gives users a list of commands and descriptions
+	return "This is a description for MoveBot which will be displayed on /c"
+}
+
+// MoveToLaneAction moves the issue in the value of the button, written as
+// "repo#number lane", the same way /move does.
+type MoveToLaneAction struct {
+}
+
+func MoveToLaneButton(issue github.Issue, lane githubservice.Lane) ResultAction {
+	return ResultAction{
+		ActionID: MoveToLaneActionID,
+		Text:     "Move to " + lane.Name,
+		Value:    IssueReference(issue) + " " + lane.Name,
+	}
+}
+
+func (h MoveToLaneAction) HandleAction(i *InteractionPayload, a *InteractionAction) string {
+	p := i.Payload()
+	p.Robot = "move"
+	p.Command = "/move"
+	p.Text = a.SelectedValue()
+	return Robots["move"].Run(p)
+}
+
+// BoardLanes are the lanes from the github configuration, or the default lanes.
+func BoardLanes() []githubservice.Lane {
+	if len(GithubConfig.Lanes) > 0 {
+		return GithubConfig.Lanes
+	}
+	return githubservice.DefaultLanes
+}
+
+func laneName(lane *githubservice.Lane) string {
+	if lane == nil {
+		return "no lane"
+	}
+	return lane.Name
+}
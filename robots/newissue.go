@@ -0,0 +1,134 @@
+package robots
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/kelseyhightower/envconfig"
+)
+
+type NewIssueBot struct {
+}
+
+var NewIssueConfig = new(GithubConfiguration)
+
+// Loads the config file and registers the bot with the server for command /newissue.
+func init() {
+	// Try to load the configuration from the environment and fall back to files in the filesystem
+	var c ConfigSpecification
+	err := envconfig.Process("github", &c)
+
+	if err != nil {
+		log.Println(err.Error())
+
+		// Fall back to reading from files if there is an error
+		loadNewIssueConfigFromFile()
+	} else {
+		err = json.Unmarshal([]byte(c.Config), NewIssueConfig)
+		if err != nil {
+			log.Println("error parsing config: ", err)
+			loadNewIssueConfigFromFile()
+		}
+	}
+	NewIssue := &NewIssueBot{}
+	RegisterRobot("newissue", NewIssue)
+}
+
+func loadNewIssueConfigFromFile() {
+	flag.Parse()
+	configFile := filepath.Join(*ConfigDirectory, "github.json")
+	if _, err := os.Stat(configFile); err == nil {
+		config, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			log.Printf("ERROR: Error opening github config: %s", err)
+			return
+		}
+		err = json.Unmarshal(config, NewIssueConfig)
+		if err != nil {
+			log.Printf("ERROR: Error parsing github config: %s", err)
+			return
+		}
+	} else {
+		log.Printf("WARNING: Could not find configuration file github.json in %s", *ConfigDirectory)
+	}
+}
+
+func (r NewIssueBot) parsePayload(p *Payload) (repo string, title string, flags map[string]string) {
+	args, flags := ParseArguments(p.Text)
+	if len(args) == 0 {
+		return "", "", flags
+	}
+	return args[0], strings.Join(args[1:], " "), flags
+}
+
+// All Robots must implement a Run command to be executed when the registered command is received.
+func (r NewIssueBot) Run(p *Payload) string {
+	repo, title, flags := r.parsePayload(p)
+	if repo == "" || title == "" {
+		return "Usage: /newissue repo title [--lane backlog] [--assign me] [--milestone name]"
+	}
+	if login, ok := flags["assign"]; ok {
+		if _, err := ResolveAssignee(login, p); err != nil {
+			return err.Error()
+		}
+	}
+
+	// If you (optionally) want to do some asynchronous work (like sending API calls to slack)
+	// you can put it in a go routine like this
+	go r.DeferredAction(p)
+	// The string returned here will be shown only to the user who executed the command
+	// and will show up as a message from slackbot.
+
+	return "Creating issue in " + repo + "..."
+}
+
+func (r NewIssueBot) DeferredAction(p *Payload) {
+
+	repo, title, flags := r.parsePayload(p)
+
+	assignee := ""
+	if login, ok := flags["assign"]; ok {
+		assignee, _ = ResolveAssignee(login, p)
+	}
+
+	service := NewGithubService(NewIssueConfig)
+	login, err := AuthorizeChange(service, NewIssueConfig.Owner, repo, p)
+
+	var issue *github.Issue
+	if err == nil {
+		body := "Reported from Slack by " + login
+		if p.ChannelName != "" {
+			body += " in #" + p.ChannelName
+		}
+		issue, err = service.CreateIssue(NewIssueConfig.Owner, repo, title, body, flags["lane"], assignee, flags["milestone"])
+	}
+
+	var text string = "Couldn't create an issue in *" + repo + "*"
+	if err == nil {
+		text = "*" + login + "* created " + IssueReference(*issue)
+	}
+
+	// Let's describe the response with the Result struct defined in result.go and send it as an
+	// IncomingWebhook message to slack that can be seen by everyone in the room. You can
+	// read the Slack API Docs (https://api.slack.com/) to know which fields are required, etc.
+	// You can also see what data is available from the command structure in definitions.go
+	result := Result{
+		Text:  text,
+		Items: BuildIssueItems(issuesOrNone(issue), err),
+	}
+
+	SendResult(p.ChannelID, result)
+}
+
+func (r NewIssueBot) Description() (description string) {
+	// In addition to a Run method, each Robot must implement a Description method which
+	// is just a simple string describing what the Robot does. This is used in the included
+	// /c command which gives users a list of commands and descriptions
+	return "This is a description for NewIssueBot which will be displayed on /c"
+}
@@ -2,6 +2,7 @@ package robots
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"io/ioutil"
 	"log"
@@ -9,8 +10,10 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/RobotsAndPencils/marvin/githubservice"
+	"github.com/google/go-github/github"
 	"github.com/kelseyhightower/envconfig"
 )
 
@@ -60,8 +63,17 @@ func loadOpenPullRequestsConfigFromFile() {
 	}
 }
 
-func (r OpenPullRequestsBot) parsePayload(p *Payload) (daysPROpen int, projectLastActiveDays int) {
-	output := strings.Split(strings.TrimSpace(p.Text), " ")
+// openPullRequestsQuery is what /openpullrequests was asked for.
+type openPullRequestsQuery struct {
+	DaysPROpen                   int
+	DaysSinceLastProjectActivity int
+	NeedsReview                  bool
+	Failing                      bool
+	Filter                       githubservice.PullRequestFilter
+}
+
+func (r OpenPullRequestsBot) parsePayload(p *Payload) (openPullRequestsQuery, error) {
+	output, flags := ParseArguments(p.Text)
 
 	var err error
 	var validDaysPROpen int = 1
@@ -80,49 +92,148 @@ func (r OpenPullRequestsBot) parsePayload(p *Payload) (daysPROpen int, projectLa
 		}
 	}
 
-	return validDaysPROpen, validDaysSinceLastProjectActivity
+	query := openPullRequestsQuery{
+		DaysPROpen:                   validDaysPROpen,
+		DaysSinceLastProjectActivity: validDaysSinceLastProjectActivity,
+	}
+	_, query.NeedsReview = flags["needs-review"]
+	_, query.Failing = flags["failing"]
+	_, query.Filter.ExcludeDrafts = flags["no-drafts"]
+
+	query.Filter.Repos = splitFlagList(flags["repo"], ", ")
+	query.Filter.Topics = splitFlagList(flags["topic"], ", ")
+	// Labels may have spaces in them, so only commas separate them.
+	query.Filter.Labels = splitFlagList(flags["label"], ",")
+	query.Filter.Authors = splitFlagList(flags["author"], ", ")
+	query.Filter.Teams = splitFlagList(flags["team"], ", ")
+	query.Filter.Base = flags["base"]
+
+	for _, group := range splitFlagList(flags["group"], ", ") {
+		members, ok := lookupNamedList(OpenPullRequestsConfig.RepoGroups, group)
+		if !ok {
+			return query, errors.New("There is no repo group " + group + ", add it to the repoGroups in github.json")
+		}
+		for _, member := range members {
+			if strings.HasPrefix(strings.ToLower(member), "topic:") {
+				query.Filter.Topics = append(query.Filter.Topics, member[len("topic:"):])
+			} else {
+				query.Filter.Repos = append(query.Filter.Repos, member)
+			}
+		}
+	}
+
+	return query, nil
+}
+
+// lookupNamedList finds a named list from the configuration, such as a repo group, ignoring case.
+func lookupNamedList(groups map[string][]string, name string) ([]string, bool) {
+	for group, members := range groups {
+		if strings.EqualFold(group, name) {
+			return members, true
+		}
+	}
+	return nil, false
+}
+
+// splitFlagList splits the value of a flag into a list at any of separators.
+func splitFlagList(value string, separators string) []string {
+	var list []string
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool { return strings.ContainsRune(separators, r) }) {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// describe says in words which pull requests the query is after.
+func (q openPullRequestsQuery) describe() string {
+	var text string = "Pull requests open for more than " + strconv.Itoa(q.DaysPROpen) + " " + DayUnit() + " in projects with activity in the last " + strconv.Itoa(q.DaysSinceLastProjectActivity) + " days"
+
+	var repos []string
+	repos = append(repos, q.Filter.Repos...)
+	for _, topic := range q.Filter.Topics {
+		repos = append(repos, "topic "+topic)
+	}
+	if len(repos) > 0 {
+		text += " matching " + strings.Join(repos, " or ")
+	}
+	var authors []string
+	authors = append(authors, q.Filter.Authors...)
+	for _, team := range q.Filter.Teams {
+		authors = append(authors, "team "+team)
+	}
+	if len(authors) > 0 {
+		text += " by " + strings.Join(authors, " or ")
+	}
+	if len(q.Filter.Labels) > 0 {
+		text += " labelled " + strings.Join(q.Filter.Labels, " or ")
+	}
+	if q.Filter.Base != "" {
+		text += " into " + q.Filter.Base
+	}
+	if q.NeedsReview && q.Failing {
+		text += " that are waiting on review and failing"
+	} else if q.NeedsReview {
+		text += " that are waiting on review"
+	} else if q.Failing {
+		text += " with failing checks"
+	}
+	if q.Filter.ExcludeDrafts {
+		text += ", leaving out drafts"
+	}
+	return text
 }
 
 // All Robots must implement a Run command to be executed when the registered command is received.
 func (r OpenPullRequestsBot) Run(p *Payload) string {
+	query, err := r.parsePayload(p)
+	if err != nil {
+		return err.Error()
+	}
+
 	// If you (optionally) want to do some asynchronous work (like sending API calls to slack)
 	// you can put it in a go routine like this
 	go r.DeferredAction(p)
 
-	daysPROpen, daysSinceLastProjectActivity := r.parsePayload(p)
-
 	// The string returned here will be shown only to the user who executed the command
 	// and will show up as a message from slackbot.
-	return "Finding pull requests that have been open longer than " + strconv.Itoa(daysPROpen) + " days in projects with activity in the last " + strconv.Itoa(daysSinceLastProjectActivity) + " days..."
+	return "Finding pull requests that have been open longer than " + strconv.Itoa(query.DaysPROpen) + " " + DayUnit() + " in projects with activity in the last " + strconv.Itoa(query.DaysSinceLastProjectActivity) + " days..."
 }
 
 func (r OpenPullRequestsBot) DeferredAction(p *Payload) {
 
-	daysPROpen, daysSinceLastProjectActivity := r.parsePayload(p)
+	query, _ := r.parsePayload(p)
 
-	service := githubservice.New(OpenPullRequestsConfig.PersonalAccessToken)
-	pullRequests, err := service.OpenPullRequests(OpenPullRequestsConfig.Owner, daysPROpen, daysSinceLastProjectActivity)
+	service := NewGithubService(OpenPullRequestsConfig)
+	pullRequests, err := service.FilteredOpenPullRequests(OpenPullRequestsConfig.Owner, query.DaysPROpen, query.DaysSinceLastProjectActivity, query.Filter)
 
-	attachments := BuildAttachmentsShowPullRequests(pullRequests, err)
+	var statuses []githubservice.PullRequestStatus
+	if err == nil {
+		statuses = service.PullRequestStatuses(pullRequests, query.NeedsReview, query.Failing)
+	}
 
-	var text string = "Pull requests open for more than " + strconv.Itoa(daysPROpen) + " days in projects with activity in the last " + strconv.Itoa(daysSinceLastProjectActivity) + " days..."
+	items := BuildPullRequestStatusItemsByRepo(statuses, err)
 
-	// Let's use the IncomingWebhook struct defined in definitions.go to form and send an
+	// Let's describe the response with the Result struct defined in result.go and send it as an
 	// IncomingWebhook message to slack that can be seen by everyone in the room. You can
 	// read the Slack API Docs (https://api.slack.com/) to know which fields are required, etc.
 	// You can also see what data is available from the command structure in definitions.go
-	response := &IncomingWebhook{
-		Channel:     p.ChannelID,
-		Username:    "Marvin",
-		Text:        text,
-		IconEmoji:   ":robot:",
-		UnfurlLinks: true,
-		Parse:       ParseStyleFull,
-		Markdown:    true,
-		Attachments: attachments,
-	}
-
-	response.Send()
+	result := Result{
+		Text:    query.describe() + "...",
+		Items:   items,
+		Actions: []ResultAction{RefreshButton(p.Robot, p.Text)},
+	}
+
+	SendResult(p.ChannelID, result)
+
+	if err == nil && len(statuses) > 0 {
+		var shown []github.PullRequest
+		for _, status := range statuses {
+			shown = append(shown, status.PullRequest)
+		}
+		SendChart(p.ChannelID, "pull-request-ages.png", PullRequestAgeChart(shown, time.Now()))
+	}
 }
 
 func (r OpenPullRequestsBot) Description() (description string) {
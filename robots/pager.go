@@ -0,0 +1,171 @@
+package robots
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Slack truncates or rejects messages with too many attachments or blocks, so
+// results are sent a page at a time.
+const (
+	defaultAttachmentPageSize = 20
+	defaultBlockPageSize      = 10
+	cachedResultLifetime      = time.Hour
+	ShowMoreActionID          = "show_more"
+
+	// Lists inside one item name this many entries and sum up the rest, as paging
+	// only splits a result between items.
+	maxListedEntries = 10
+)
+
+type cachedResult struct {
+	result  Result
+	expires time.Time
+}
+
+var resultCache = make(map[string]cachedResult)
+var resultCacheMutex sync.Mutex
+
+// SendResult posts a result to channel. Results that do not fit in one message
+// are split over several messages, or with Block Kit the first page is posted
+// with a "Show more" button that fetches the next page from a cached copy.
+func SendResult(channel string, result Result) error {
+	return sendPages(result, func(page Result) error {
+		return NewIncomingWebhook(channel, page).Send()
+	})
+}
+
+// sendPages sends a result a page at a time with send, the way SendResult does.
+func sendPages(result Result, send func(Result) error) error {
+	size := pageSize()
+	if len(result.Items) <= size {
+		return send(result)
+	}
+
+	if Config.BlockKit {
+		id := cacheResult(result)
+		first, _ := CachedResultPage(id, 0)
+		return send(first)
+	}
+
+	for page := 0; page*size < len(result.Items); page++ {
+		err := send(resultPage(result, page, size, ""))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CachedResultPage returns page (counting from 0) of a result cached by SendResult.
+func CachedResultPage(id string, page int) (Result, bool) {
+	resultCacheMutex.Lock()
+	cached, ok := resultCache[id]
+	resultCacheMutex.Unlock()
+
+	if !ok || time.Now().After(cached.expires) || page < 0 || page*pageSize() >= len(cached.result.Items) {
+		return Result{}, false
+	}
+	return resultPage(cached.result, page, pageSize(), id), true
+}
+
+// ParseShowMoreValue splits the value of a "Show more" button into the cached
+// result id and the page it asks for.
+func ParseShowMoreValue(value string) (id string, page int, ok bool) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return "", 0, false
+	}
+	page, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, false
+	}
+	return parts[0], page, true
+}
+
+func pageSize() int {
+	if Config.PageSize > 0 {
+		return Config.PageSize
+	} else if Config.BlockKit {
+		return defaultBlockPageSize
+	}
+	return defaultAttachmentPageSize
+}
+
+// resultPage cuts one page out of result and says where it sits in the whole.
+// With a cache id the page also says how many items are hidden and gets a
+// "Show more" button for the following page.
+func resultPage(result Result, page int, size int, id string) Result {
+	start := page * size
+	end := start + size
+	if end > len(result.Items) {
+		end = len(result.Items)
+	}
+	total := len(result.Items)
+	hidden := total - end
+
+	paged := Result{
+		Text:    result.Text,
+		Items:   result.Items[start:end],
+		Actions: result.Actions,
+	}
+	if page > 0 {
+		paged.Text += " (continued)"
+	}
+
+	paged.Footer = "Showing " + strconv.Itoa(start+1) + "-" + strconv.Itoa(end) + " of " + strconv.Itoa(total)
+	if hidden > 0 && id != "" {
+		paged.Footer += ", " + strconv.Itoa(hidden) + " more " + pluralize(hidden, "item", "items") + " hidden"
+		paged.Actions = append([]ResultAction{{
+			ActionID: ShowMoreActionID,
+			Text:     "Show more",
+			Value:    id + ":" + strconv.Itoa(page+1),
+		}}, paged.Actions...)
+	}
+	paged.Footer += "."
+
+	return paged
+}
+
+func cacheResult(result Result) string {
+	bytes := make([]byte, 8)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		log.Println("Couldn't generate result id:", err)
+	}
+	id := hex.EncodeToString(bytes)
+
+	resultCacheMutex.Lock()
+	defer resultCacheMutex.Unlock()
+
+	now := time.Now()
+	for key, cached := range resultCache {
+		if now.After(cached.expires) {
+			delete(resultCache, key)
+		}
+	}
+	resultCache[id] = cachedResult{result: result, expires: now.Add(cachedResultLifetime)}
+
+	return id
+}
+
+func pluralize(count int, singular string, plural string) string {
+	if count == 1 {
+		return singular
+	}
+	return plural
+}
+
+// limitList keeps the first few entries of a list and sums up the rest.
+func limitList(entries []string) []string {
+	if len(entries) <= maxListedEntries {
+		return entries
+	}
+	hidden := len(entries) - maxListedEntries
+	return append(entries[:maxListedEntries:maxListedEntries], "_and "+strconv.Itoa(hidden)+" more_")
+}
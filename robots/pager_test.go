@@ -0,0 +1,90 @@
+package robots
+
+import (
+	"strconv"
+	"testing"
+
+	. "github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func numberedResult(count int) Result {
+	result := Result{Text: "Issues"}
+	for i := 1; i <= count; i++ {
+		result.Items = append(result.Items, ResultItem{Text: strconv.Itoa(i)})
+	}
+	return result
+}
+
+func TestPager(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Pages", func() {
+		g.It("Should cut a page out of a result", func() {
+			page := resultPage(numberedResult(5), 1, 2, "")
+
+			Expect(page.Text).To(Equal("Issues (continued)"))
+			Expect(page.Items).To(Equal([]ResultItem{{Text: "3"}, {Text: "4"}}))
+			Expect(page.Footer).To(Equal("Showing 3-4 of 5."))
+			Expect(page.Actions).To(BeEmpty())
+		})
+
+		g.It("Should offer the next page of a cached result", func() {
+			page := resultPage(numberedResult(5), 0, 2, "abc")
+
+			Expect(page.Text).To(Equal("Issues"))
+			Expect(page.Footer).To(Equal("Showing 1-2 of 5, 3 more items hidden."))
+			Expect(page.Actions).To(HaveLen(1))
+			Expect(page.Actions[0].ActionID).To(Equal(ShowMoreActionID))
+			Expect(page.Actions[0].Value).To(Equal("abc:1"))
+
+			last := resultPage(numberedResult(5), 2, 2, "abc")
+			Expect(last.Items).To(Equal([]ResultItem{{Text: "5"}}))
+			Expect(last.Footer).To(Equal("Showing 5-5 of 5."))
+			Expect(last.Actions).To(BeEmpty())
+		})
+
+		g.It("Should sum up the end of long lists", func() {
+			var entries []string
+			for i := 1; i <= maxListedEntries+3; i++ {
+				entries = append(entries, strconv.Itoa(i))
+			}
+
+			limited := limitList(entries)
+
+			Expect(limited).To(HaveLen(maxListedEntries + 1))
+			Expect(limited[maxListedEntries]).To(Equal("_and 3 more_"))
+			Expect(limitList(entries[:2])).To(Equal([]string{"1", "2"}))
+		})
+
+		g.It("Should page through a cached result", func() {
+			Config.PageSize = 2
+			defer func() { Config.PageSize = 0 }()
+
+			id := cacheResult(numberedResult(3))
+			page, ok := CachedResultPage(id, 1)
+			Expect(ok).To(BeTrue())
+			Expect(page.Items).To(Equal([]ResultItem{{Text: "3"}}))
+
+			_, ok = CachedResultPage(id, 2)
+			Expect(ok).To(BeFalse())
+			_, ok = CachedResultPage("unknown", 0)
+			Expect(ok).To(BeFalse())
+		})
+
+		g.It("Should read the value of a Show more button", func() {
+			id, page, ok := ParseShowMoreValue("abc:2")
+			Expect(ok).To(BeTrue())
+			Expect(id).To(Equal("abc"))
+			Expect(page).To(Equal(2))
+
+			_, _, ok = ParseShowMoreValue("abc")
+			Expect(ok).To(BeFalse())
+			_, _, ok = ParseShowMoreValue("abc:two")
+			Expect(ok).To(BeFalse())
+		})
+	})
+}
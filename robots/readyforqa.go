@@ -9,7 +9,6 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/RobotsAndPencils/marvin/githubservice"
 	"github.com/kelseyhightower/envconfig"
 )
 
@@ -72,27 +71,20 @@ func (r ReadyForQABot) Run(p *Payload) string {
 
 func (r ReadyForQABot) DeferredAction(p *Payload) {
 
-	service := githubservice.New(ReadyForQAConfig.PersonalAccessToken)
-	issues, err := service.ReadyForQA(ReadyForQAConfig.Owner, strings.TrimSpace(p.Text))
+	service := NewGithubService(ReadyForQAConfig)
+	issues, err := service.IssuesInLane(ReadyForQAConfig.Owner, strings.TrimSpace(p.Text), BoardLanes(), "ready for qa")
 
-	attachments := BuildAttachments(issues, err)
-
-	// Let's use the IncomingWebhook struct defined in definitions.go to form and send an
+	// Let's describe the response with the Result struct defined in result.go and send it as an
 	// IncomingWebhook message to slack that can be seen by everyone in the room. You can
 	// read the Slack API Docs (https://api.slack.com/) to know which fields are required, etc.
 	// You can also see what data is available from the command structure in definitions.go
-	response := &IncomingWebhook{
-		Channel:     p.ChannelID,
-		Username:    "Marvin",
-		Text:        "Ready for QA for repo *" + p.Text + "*",
-		IconEmoji:   ":robot:",
-		UnfurlLinks: true,
-		Parse:       ParseStyleFull,
-		Markdown:    true,
-		Attachments: attachments,
+	result := Result{
+		Text:    "Ready for QA for repo *" + p.Text + "*",
+		Items:   append(BuildEstimateItems(issues), BuildIssueItems(issues, err)...),
+		Actions: []ResultAction{RefreshButton(p.Robot, p.Text)},
 	}
 
-	response.Send()
+	SendResult(p.ChannelID, result)
 }
 
 func (r ReadyForQABot) Description() (description string) {
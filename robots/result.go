@@ -0,0 +1,282 @@
+package robots
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Result is what a robot reports back to a channel. It describes the content
+// only; RenderBlocks and RenderAttachments decide how Slack will show it.
+type Result struct {
+	Text    string
+	Items   []ResultItem
+	Footer  string
+	Actions []ResultAction
+}
+
+type ResultItem struct {
+	Title     string
+	TitleLink string
+	Text      string
+	Context   string
+	Color     string
+	Fields    []ResultField
+	Actions   []ResultAction
+}
+
+type ResultField struct {
+	Title string
+	Value string
+	Short bool
+}
+
+// ResultAction is a button shown with an item. Buttons with a URL open it,
+// the others are sent back to Marvin with their ActionID and Value.
+type ResultAction struct {
+	ActionID string
+	Text     string
+	Value    string
+	URL      string
+	Style    string
+}
+
+// NewIncomingWebhook forms the IncomingWebhook message for a result, using Block Kit
+// when it is enabled in the configuration and legacy attachments otherwise. Both show
+// the buttons of the result and its items.
+func NewIncomingWebhook(channel string, result Result) *IncomingWebhook {
+	response := &IncomingWebhook{
+		Channel:     channel,
+		Username:    "Marvin",
+		Text:        result.Text,
+		IconEmoji:   ":robot:",
+		UnfurlLinks: true,
+		Parse:       ParseStyleFull,
+		Markdown:    true,
+	}
+
+	if Config.BlockKit {
+		response.Blocks = RenderBlocks(result)
+	} else {
+		response.Attachments = RenderAttachments(result.Items)
+		if result.Footer != "" {
+			response.Attachments = append(response.Attachments, Attachment{Fallback: result.Footer, Text: "_" + result.Footer + "_", MarkdownIn: []MarkdownField{MarkdownFieldText}})
+		}
+		if len(result.Actions) > 0 {
+			response.Attachments = append(response.Attachments, Attachment{
+				Fallback:   actionsFallback(result.Actions),
+				CallbackID: AttachmentCallbackID,
+				Actions:    attachmentActions(result.Actions),
+			})
+		}
+	}
+
+	return response
+}
+
+func RenderAttachments(items []ResultItem) []Attachment {
+	var attachments []Attachment
+
+	for _, item := range items {
+		text := item.Text
+		if item.Context != "" {
+			if text != "" {
+				text += "\n"
+			}
+			text += item.Context
+		}
+
+		var fields []AttachmentField
+		for _, field := range item.Fields {
+			fields = append(fields, AttachmentField{Title: field.Title, Value: field.Value, Short: field.Short})
+		}
+
+		fallback := item.Title
+		if fallback == "" {
+			fallback = item.Text
+		}
+
+		attachment := &Attachment{
+			Fallback:   fallback,
+			Title:      item.Title,
+			TitleLink:  item.TitleLink,
+			Text:       text,
+			Color:      item.Color,
+			Fields:     fields,
+			MarkdownIn: []MarkdownField{MarkdownFieldTitle, MarkdownFieldText, MarkdownFieldFields},
+		}
+		if len(item.Actions) > 0 {
+			attachment.CallbackID = AttachmentCallbackID
+			attachment.Actions = attachmentActions(item.Actions)
+		}
+		attachments = append(attachments, *attachment)
+	}
+
+	return attachments
+}
+
+func attachmentActions(actions []ResultAction) []AttachmentAction {
+	var buttons []AttachmentAction
+	for _, action := range actions {
+		buttons = append(buttons, AttachmentAction{
+			Name:  action.ActionID,
+			Text:  action.Text,
+			Type:  "button",
+			Value: action.Value,
+			URL:   action.URL,
+			Style: action.Style,
+		})
+	}
+	return buttons
+}
+
+func actionsFallback(actions []ResultAction) string {
+	var texts []string
+	for _, action := range actions {
+		texts = append(texts, action.Text)
+	}
+	return strings.Join(texts, ", ")
+}
+
+// Slack rejects messages whose section text is longer than maxSectionText, whose
+// section fields are longer than maxFieldText or that have more than maxFields fields.
+const (
+	maxSectionText = 3000
+	maxFieldText   = 2000
+	maxFields      = 10
+)
+
+// RenderBlocks forms the Block Kit blocks for a result. Blocks have no color, so an
+// item's color is shown as a colored circle in front of its title.
+func RenderBlocks(result Result) []Block {
+	var blocks []Block
+
+	if result.Text != "" {
+		blocks = append(blocks, Block{Type: BlockTypeSection, Text: markdownText(truncate(result.Text, maxSectionText))})
+	}
+
+	for _, item := range result.Items {
+		blocks = append(blocks, Block{Type: BlockTypeDivider})
+
+		var lines []string
+		if item.Title != "" {
+			if item.TitleLink != "" {
+				lines = append(lines, "*<"+item.TitleLink+"|"+escapeLinkText(item.Title)+">*")
+			} else {
+				lines = append(lines, "*"+item.Title+"*")
+			}
+		}
+		if item.Text != "" {
+			lines = append(lines, item.Text)
+		}
+		if len(lines) > 0 && item.Color != "" {
+			lines[0] = colorEmoji(item.Color) + " " + lines[0]
+		}
+
+		section := Block{Type: BlockTypeSection}
+		if len(lines) > 0 {
+			section.Text = markdownText(truncate(strings.Join(lines, "\n"), maxSectionText))
+		}
+		for i, field := range item.Fields {
+			if i == maxFields {
+				break
+			}
+			section.Fields = append(section.Fields, *markdownText(truncate("*"+field.Title+"*\n"+field.Value, maxFieldText)))
+		}
+		if section.Text != nil || len(section.Fields) > 0 {
+			blocks = append(blocks, section)
+		}
+
+		if item.Context != "" {
+			blocks = append(blocks, Block{Type: BlockTypeContext, Elements: []interface{}{markdownText(truncate(item.Context, maxSectionText))}})
+		}
+
+		if len(item.Actions) > 0 {
+			blocks = append(blocks, actionsBlock(item.Actions))
+		}
+	}
+
+	if result.Footer != "" {
+		blocks = append(blocks, Block{Type: BlockTypeDivider})
+		blocks = append(blocks, Block{Type: BlockTypeContext, Elements: []interface{}{markdownText(truncate(result.Footer, maxSectionText))}})
+	}
+	if len(result.Actions) > 0 {
+		blocks = append(blocks, actionsBlock(result.Actions))
+	}
+
+	return blocks
+}
+
+func actionsBlock(actions []ResultAction) Block {
+	var elements []interface{}
+	for _, action := range actions {
+		elements = append(elements, BlockElement{
+			Type:     "button",
+			Text:     &TextObject{Type: TextTypePlain, Text: action.Text},
+			ActionID: action.ActionID,
+			Value:    action.Value,
+			URL:      action.URL,
+			Style:    action.Style,
+		})
+	}
+	return Block{Type: BlockTypeActions, Elements: elements}
+}
+
+func markdownText(text string) *TextObject {
+	return &TextObject{Type: TextTypeMarkdown, Text: text}
+}
+
+// truncate shortens text to at most limit characters, ending it with an ellipsis.
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// colorEmoji picks the colored circle closest to a color written as #rrggbb.
+func colorEmoji(color string) string {
+	hex := strings.TrimPrefix(color, "#")
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return ":white_circle:"
+	}
+	r, g, b := float64(value>>16&0xff), float64(value>>8&0xff), float64(value&0xff)
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	if max-min < 40 {
+		if max < 80 {
+			return ":black_circle:"
+		}
+		return ":white_circle:"
+	}
+
+	var hue float64
+	switch max {
+	case r:
+		hue = math.Mod((g-b)/(max-min)*60+360, 360)
+	case g:
+		hue = (b-r)/(max-min)*60 + 120
+	default:
+		hue = (r-g)/(max-min)*60 + 240
+	}
+	switch {
+	case hue < 15 || hue >= 330:
+		return ":red_circle:"
+	case hue < 45:
+		return ":large_orange_circle:"
+	case hue < 70:
+		return ":large_yellow_circle:"
+	case hue < 170:
+		return ":large_green_circle:"
+	case hue < 255:
+		return ":large_blue_circle:"
+	}
+	return ":large_purple_circle:"
+}
+
+// Slack link text ends at the first | or >, so those have to go.
+func escapeLinkText(text string) string {
+	return strings.NewReplacer("|", "/", ">", "&gt;", "<", "&lt;").Replace(text)
+}
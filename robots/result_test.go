@@ -0,0 +1,100 @@
+package robots
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func TestResult(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Attachments", func() {
+		g.It("Should show the title, text, context, color and fields of items", func() {
+			attachments := RenderAttachments([]ResultItem{{
+				Title:   "#1 Broken",
+				Text:    "Opened by alice",
+				Context: "3 days ago",
+				Color:   "#ff0000",
+				Fields:  []ResultField{{Title: "Lane", Value: "Backlog", Short: true}},
+			}})
+
+			Expect(attachments).To(HaveLen(1))
+			Expect(attachments[0].Fallback).To(Equal("#1 Broken"))
+			Expect(attachments[0].Text).To(Equal("Opened by alice\n3 days ago"))
+			Expect(attachments[0].Color).To(Equal("#ff0000"))
+			Expect(attachments[0].Fields).To(Equal([]AttachmentField{{Title: "Lane", Value: "Backlog", Short: true}}))
+			Expect(attachments[0].Actions).To(BeEmpty())
+		})
+
+		g.It("Should show the buttons of items and of the result", func() {
+			refresh := RefreshButton("inprogress", "marvin")
+			result := Result{
+				Items:   []ResultItem{{Title: "#1 Broken", Actions: []ResultAction{{ActionID: AssignToMeActionID, Text: "Assign to me", Value: "marvin#1"}}}},
+				Actions: []ResultAction{refresh},
+			}
+
+			attachments := NewIncomingWebhook("C123", result).Attachments
+
+			Expect(attachments).To(HaveLen(2))
+			Expect(attachments[0].CallbackID).To(Equal(AttachmentCallbackID))
+			Expect(attachments[0].Actions).To(Equal([]AttachmentAction{{Name: AssignToMeActionID, Text: "Assign to me", Type: "button", Value: "marvin#1"}}))
+			Expect(attachments[1].CallbackID).To(Equal(AttachmentCallbackID))
+			Expect(attachments[1].Actions[0].Name).To(Equal(RefreshActionID))
+			Expect(attachments[1].Fallback).To(Equal("Refresh"))
+		})
+	})
+
+	g.Describe("Blocks", func() {
+		g.It("Should show an item as a section with its context and buttons", func() {
+			blocks := RenderBlocks(Result{
+				Text: "Issues",
+				Items: []ResultItem{{
+					Title:     "#1 Broken",
+					TitleLink: "https://github.com/owner/marvin/issues/1",
+					Text:      "Opened by alice",
+					Context:   "3 days ago",
+					Actions:   []ResultAction{RefreshButton("inprogress", "")},
+				}},
+			})
+
+			Expect(blocks).To(HaveLen(5))
+			Expect(blocks[0].Text.Text).To(Equal("Issues"))
+			Expect(blocks[1].Type).To(Equal(BlockTypeDivider))
+			Expect(blocks[2].Text.Text).To(Equal("*<https://github.com/owner/marvin/issues/1|#1 Broken>*\nOpened by alice"))
+			Expect(blocks[3].Type).To(Equal(BlockTypeContext))
+			Expect(blocks[4].Type).To(Equal(BlockTypeActions))
+		})
+
+		g.It("Should leave the text out of sections with only fields", func() {
+			blocks := RenderBlocks(Result{Items: []ResultItem{{Fields: []ResultField{{Title: "Lane", Value: "Backlog"}}}}})
+
+			Expect(blocks).To(HaveLen(2))
+			Expect(blocks[1].Text).To(BeNil())
+			Expect(blocks[1].Fields).To(Equal([]TextObject{{Type: TextTypeMarkdown, Text: "*Lane*\nBacklog"}}))
+		})
+
+		g.It("Should show the color of an item as a colored circle", func() {
+			blocks := RenderBlocks(Result{Items: []ResultItem{{Title: "Stale", Color: "#ff1010"}}})
+
+			Expect(blocks[1].Text.Text).To(Equal(":red_circle: *Stale*"))
+			Expect(colorEmoji("#36a64f")).To(Equal(":large_green_circle:"))
+			Expect(colorEmoji("#439FE0")).To(Equal(":large_blue_circle:"))
+			Expect(colorEmoji("#A0A0A0")).To(Equal(":white_circle:"))
+		})
+
+		g.It("Should shorten text to what Slack accepts", func() {
+			long := strings.Repeat("a", maxSectionText+10)
+			blocks := RenderBlocks(Result{Text: long, Items: []ResultItem{{Text: long}}})
+
+			Expect([]rune(blocks[0].Text.Text)).To(HaveLen(maxSectionText))
+			Expect(blocks[0].Text.Text).To(HaveSuffix("…"))
+			Expect([]rune(blocks[2].Text.Text)).To(HaveLen(maxSectionText))
+		})
+	})
+}
@@ -0,0 +1,154 @@
+package robots
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/RobotsAndPencils/marvin/githubservice"
+	"github.com/kelseyhightower/envconfig"
+)
+
+type ReviewsBot struct {
+}
+
+var ReviewsConfig = new(GithubConfiguration)
+
+// Loads the config file and registers the bot with the server for command /reviews.
+func init() {
+	// Try to load the configuration from the environment and fall back to files in the filesystem
+	var c ConfigSpecification
+	err := envconfig.Process("github", &c)
+
+	if err != nil {
+		log.Println(err.Error())
+
+		// Fall back to reading from files if there is an error
+		loadReviewsConfigFromFile()
+	} else {
+		err = json.Unmarshal([]byte(c.Config), ReviewsConfig)
+		if err != nil {
+			log.Println("error parsing config: ", err)
+			loadReviewsConfigFromFile()
+		}
+	}
+	Reviews := &ReviewsBot{}
+	RegisterRobot("reviews", Reviews)
+}
+
+func loadReviewsConfigFromFile() {
+	flag.Parse()
+	configFile := filepath.Join(*ConfigDirectory, "github.json")
+	if _, err := os.Stat(configFile); err == nil {
+		config, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			log.Printf("ERROR: Error opening github config: %s", err)
+			return
+		}
+		err = json.Unmarshal(config, ReviewsConfig)
+		if err != nil {
+			log.Printf("ERROR: Error parsing github config: %s", err)
+			return
+		}
+	} else {
+		log.Printf("WARNING: Could not find configuration file github.json in %s", *ConfigDirectory)
+	}
+}
+
+func (r ReviewsBot) parsePayload(p *Payload) (login string, err error) {
+	args, _ := ParseArguments(p.Text)
+	if len(args) > 1 {
+		return "", errors.New("Usage: /reviews [me|login]")
+	}
+
+	login = "me"
+	if len(args) == 1 {
+		login = args[0]
+	}
+	return ResolveLogin(login, p)
+}
+
+// All Robots must implement a Run command to be executed when the registered command is received.
+func (r ReviewsBot) Run(p *Payload) string {
+	login, err := r.parsePayload(p)
+	if err != nil {
+		return err.Error()
+	}
+
+	// If you (optionally) want to do some asynchronous work (like sending API calls to slack)
+	// you can put it in a go routine like this
+	go r.DeferredAction(p)
+	// The string returned here will be shown only to the user who executed the command
+	// and will show up as a message from slackbot.
+
+	return "Finding pull requests waiting on " + login + "..."
+}
+
+func (r ReviewsBot) DeferredAction(p *Payload) {
+
+	login, _ := r.parsePayload(p)
+
+	service := NewGithubService(ReviewsConfig)
+	queue, err := service.ReviewQueue(ReviewsConfig.Owner, login)
+
+	// Let's describe the response with the Result struct defined in result.go and send it as an
+	// IncomingWebhook message to slack that can be seen by everyone in the room. You can
+	// read the Slack API Docs (https://api.slack.com/) to know which fields are required, etc.
+	// You can also see what data is available from the command structure in definitions.go
+	result := Result{
+		Text:    "Pull requests waiting on *" + login + "* (" + strconv.Itoa(len(queue)) + ")",
+		Items:   BuildReviewQueueItems(queue, err),
+		Actions: []ResultAction{RefreshButton(p.Robot, p.Text)},
+	}
+
+	SendResult(p.ChannelID, result)
+}
+
+// BuildReviewQueueItems lists the pull requests in a review queue, coloured by age
+// like /openpullrequests, with why each one is waiting on the reviewer.
+func BuildReviewQueueItems(queue []githubservice.ReviewRequest, err error) []ResultItem {
+	var items []ResultItem
+	if err != nil {
+		return append(items, ResultItem{
+			Text:  "Error: " + err.Error(),
+			Color: "#ff0000",
+		})
+	}
+
+	for _, request := range queue {
+		pullRequest := request.PullRequest
+		var numberOfDays = AgeInDays(*pullRequest.CreatedAt)
+
+		var author = "_Unknown_"
+		if pullRequest.User != nil && pullRequest.User.Login != nil {
+			author = "_" + *pullRequest.User.Login + "_"
+		}
+
+		items = append(items, ResultItem{
+			Title:     IssueReference(pullRequest) + " - " + *pullRequest.Title,
+			TitleLink: *pullRequest.HTMLURL,
+			Text:      "*" + strconv.FormatFloat(numberOfDays, 'f', 0, 64) + " " + DayUnit() + " old* by " + author + ", " + request.Reason,
+			Color:     colorForPullRequestAge(numberOfDays),
+		})
+	}
+
+	if len(items) == 0 {
+		items = append(items, ResultItem{
+			Text:  "Nothing is waiting on a review",
+			Color: "#A0A0A0",
+		})
+	}
+	return items
+}
+
+func (r ReviewsBot) Description() (description string) {
+	// In addition to a Run method, each Robot must implement a Description method which
+	// is just a simple string describing what the Robot does. This is used in the included
+	// /c command which gives users a list of commands and descriptions
+	return "This is a description for ReviewsBot which will be displayed on /c"
+}
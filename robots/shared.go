@@ -3,6 +3,7 @@ package robots
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -14,8 +15,11 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/RobotsAndPencils/marvin/githubservice"
+	"github.com/RobotsAndPencils/marvin/store"
 	"github.com/google/go-github/github"
 	"github.com/kelseyhightower/envconfig"
 )
@@ -24,6 +28,14 @@ var Robots = make(map[string]Robot)
 var Config = new(Configuration)
 var ConfigDirectory = flag.String("c", ".", "Configuration directory (default .)")
 
+var dataStore *store.Store
+var dataStoreOnce sync.Once
+
+// GitHub Apps are shared between robots so their installation tokens are cached
+// across commands instead of being requested again for every one.
+var githubApps = make(map[int]*githubservice.App)
+var githubAppsMutex sync.Mutex
+
 // CaseInsensitiveSorter sorts String.
 type CaseInsensitiveSorter []string
 
@@ -83,6 +95,148 @@ func RegisterRobot(command string, r Robot) {
 	}
 }
 
+// DataStore is where Marvin keeps what it has to remember between restarts, such as
+// channel subscriptions. It lives in the Redis server at storeurl in the configuration
+// or REDIS_URL, or else in the file named by storepath. Heroku wipes the filesystem
+// of a dyno when it restarts, so Marvin refuses to start there without Redis.
+func DataStore() *store.Store {
+	dataStoreOnce.Do(func() {
+		redisURL := Config.StoreURL
+		if redisURL == "" {
+			redisURL = os.Getenv("REDIS_URL")
+		}
+		if redisURL != "" {
+			options := store.RedisOptions{CAFile: Config.StoreCA, SkipVerify: Config.StoreSkipVerify}
+			if options.CAFile != "" && !filepath.IsAbs(options.CAFile) {
+				options.CAFile = filepath.Join(*ConfigDirectory, options.CAFile)
+			}
+			var err error
+			dataStore, err = store.OpenRedis(redisURL, options)
+			if err != nil {
+				log.Fatal("Error opening data store: ", err)
+			}
+			return
+		}
+		if os.Getenv("DYNO") != "" {
+			log.Fatal("Heroku loses files when a dyno restarts, add Heroku Redis or set storeurl so Marvin doesn't forget subscriptions, digests and scheduled runs")
+		}
+
+		path := Config.StorePath
+		if path == "" {
+			path = "marvin-data.json"
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(*ConfigDirectory, path)
+		}
+
+		var err error
+		dataStore, err = store.Open(path)
+		if err != nil {
+			log.Fatal("Error opening data store: ", err)
+		}
+	})
+	return dataStore
+}
+
+// NewGithubService returns a service that authenticates as the configured GitHub App
+// when there is one and falls back to the personal access token otherwise. The
+// service uses the lanes and the working calendar from the configuration.
+func NewGithubService(config *GithubConfiguration) *githubservice.GithubService {
+	service := githubservice.New(config.PersonalAccessToken)
+
+	if config.AppID != 0 {
+		app, err := githubApp(config)
+		if err != nil {
+			log.Printf("ERROR: Could not authenticate as GitHub App %d, using personal access token: %s", config.AppID, err)
+		} else {
+			service = githubservice.NewWithTokenSource(app.TokenSource(config.Owner))
+		}
+	}
+
+	service.Lanes = config.Lanes
+	service.Calendar = WorkCalendar()
+	return service
+}
+
+func githubApp(config *GithubConfiguration) (*githubservice.App, error) {
+	githubAppsMutex.Lock()
+	defer githubAppsMutex.Unlock()
+
+	if app, ok := githubApps[config.AppID]; ok {
+		return app, nil
+	}
+
+	privateKey := []byte(config.PrivateKey)
+	if config.PrivateKeyPath != "" {
+		keyFile := config.PrivateKeyPath
+		if !filepath.IsAbs(keyFile) {
+			keyFile = filepath.Join(*ConfigDirectory, keyFile)
+		}
+		var err error
+		privateKey, err = ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	app, err := githubservice.NewApp(config.AppID, privateKey, config.Installations)
+	if err != nil {
+		return nil, err
+	}
+	githubApps[config.AppID] = app
+	return app, nil
+}
+
+// ParseArguments splits the text of a command into its positional arguments and
+// its --flags. The value of a flag is every word up to the next flag, so
+// "--lane ready for qa" works, and a flag with nothing after it has an empty value.
+func ParseArguments(text string) (args []string, flags map[string]string) {
+	flags = make(map[string]string)
+	name := ""
+	for _, word := range strings.Fields(text) {
+		if strings.HasPrefix(word, "--") && len(word) > 2 {
+			name = strings.ToLower(word[2:])
+			flags[name] = ""
+		} else if name != "" {
+			flags[name] = strings.TrimSpace(flags[name] + " " + word)
+		} else {
+			args = append(args, word)
+		}
+	}
+	return args, flags
+}
+
+// ParseDays reads a period such as "30d", "6w" or a plain number of days.
+func ParseDays(period string) (int, error) {
+	text := strings.ToLower(strings.TrimSpace(period))
+	multiplier := 1
+	if strings.HasSuffix(text, "w") {
+		multiplier = 7
+		text = strings.TrimSuffix(text, "w")
+	} else {
+		text = strings.TrimSuffix(text, "d")
+	}
+
+	days, err := strconv.Atoi(text)
+	if err != nil || days <= 0 {
+		return 0, errors.New("I don't understand the period " + period + ", try 30d or 6w")
+	}
+	return days * multiplier, nil
+}
+
+// FormatDuration describes a duration in days, or hours when it is shorter than a day.
+func FormatDuration(d time.Duration) string {
+	if d < 24*time.Hour {
+		hours := int(d.Hours() + 0.5)
+		return strconv.Itoa(hours) + " " + pluralize(hours, "hour", "hours")
+	}
+	days := d.Hours() / 24
+	if days < 10 {
+		return strconv.FormatFloat(days, 'f', 1, 64) + " days"
+	}
+	return strconv.Itoa(int(days+0.5)) + " days"
+}
+
 func (i *IncomingWebhook) Send() error {
 	webhook := url.URL{
 		Scheme: "https",
@@ -102,6 +256,10 @@ func (i *IncomingWebhook) Send() error {
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
 		message := fmt.Sprintf("ERROR: Non-200 Response from Slack Incoming Webhook API: %s", resp.Status)
 		log.Println(message)
@@ -109,13 +267,13 @@ func (i *IncomingWebhook) Send() error {
 	return err
 }
 
-func BuildAttachments(issues []github.Issue, err error) []Attachment {
-	return BuildAttachmentsShowRepo(issues, false, true, err)
+func BuildIssueItems(issues []github.Issue, err error) []ResultItem {
+	return BuildIssueItemsShowRepo(issues, false, true, err)
 }
 
-func BuildAttachmentsShowRepo(issues []github.Issue, showrepo bool, showAssigned bool, err error) []Attachment {
+func BuildIssueItemsShowRepo(issues []github.Issue, showrepo bool, showAssigned bool, err error) []ResultItem {
 
-	var attachments []Attachment
+	var items []ResultItem
 
 	if err == nil {
 		if len(issues) > 0 {
@@ -166,97 +324,258 @@ func BuildAttachmentsShowRepo(issues []github.Issue, showrepo bool, showAssigned
 					text += " - [" + joinedLabels + "]"
 				}
 
-				markdownFields := []MarkdownField{MarkdownFieldTitle, MarkdownFieldText}
-
-				attachment := &Attachment{
-					Title:      title,
-					TitleLink:  *issue.HTMLURL,
-					Text:       text,
-					Color:      color,
-					MarkdownIn: markdownFields,
+				item := &ResultItem{
+					Title:     title,
+					TitleLink: *issue.HTMLURL,
+					Text:      text,
+					Color:     color,
+				}
+				if issue.Assignee == nil {
+					item.Actions = append(item.Actions, AssignToMeButton(issue))
+				}
+				if inProgress, ok := laneBeforeInProgress(issue); ok {
+					item.Actions = append(item.Actions, MoveToLaneButton(issue, inProgress))
 				}
 
-				attachments = append(attachments, *attachment)
+				items = append(items, *item)
 			}
 		} else {
 
-			attachment := &Attachment{
+			item := &ResultItem{
 				Text:  "No Issues Found.",
 				Color: "#ff1010",
 			}
 
-			attachments = append(attachments, *attachment)
+			items = append(items, *item)
 		}
 
 	} else {
-		attachment := &Attachment{
+		item := &ResultItem{
 			Text:  "Error: " + err.Error(),
 			Color: "#ff0000",
 		}
 
-		attachments = append(attachments, *attachment)
+		items = append(items, *item)
 	}
 
-	return attachments
+	return items
 }
 
-func BuildAttachmentsShowPullRequests(openPRs []github.PullRequest, err error) []Attachment {
-	var attachments []Attachment
+// laneBeforeInProgress returns the In Progress lane when the issue has not got there yet.
+func laneBeforeInProgress(issue github.Issue) (githubservice.Lane, bool) {
+	lanes := BoardLanes()
+	inProgress, err := githubservice.FindLane(lanes, "in progress")
+	if err != nil {
+		return githubservice.Lane{}, false
+	}
+	current := githubservice.LaneForIssue(lanes, issue)
+	for _, lane := range lanes {
+		if lane.Name == inProgress.Name {
+			return *inProgress, false
+		}
+		if current != nil && lane.Name == current.Name {
+			return *inProgress, true
+		}
+	}
+	return *inProgress, false
+}
+
+func BuildPullRequestItems(openPRs []github.PullRequest, err error) []ResultItem {
+	var items []ResultItem
 
 	if err == nil {
 		if len(openPRs) > 0 {
 			for _, pullRequest := range openPRs {
-				var numberOfDays = time.Since(*pullRequest.CreatedAt).Hours() / 24
-
-				// Setup a color gradient to quickly show PR age for someone scanning the list
-				var colour = "#ffe7e7"
-				if numberOfDays > 30 {
-					colour = "#ff1010"
-				} else if numberOfDays > 7 {
-					colour = "#ff5757"
-				} else if numberOfDays > 3 {
-					colour = "#ff9f9f"
-				}
-
-				var assigned string
-				if pullRequest.User != nil {
-					assigned = "_" + *pullRequest.User.Login + "_"
-				} else {
-					assigned = "_Unassigned_"
-				}
-
-				var title string = "PR #" + strconv.Itoa(*pullRequest.Number) + " - " + *pullRequest.Title
-				var description string = "*" + strconv.FormatFloat(numberOfDays, 'f', 0, 64) + " days in " + *pullRequest.Head.Repo.Name + "* created by " + assigned
-				markdownFields := []MarkdownField{MarkdownFieldTitle, MarkdownFieldText}
-				attachment := &Attachment{
-					Title:      title,
-					TitleLink:  *pullRequest.HTMLURL,
-					Text:       description,
-					Color:      colour,
-					MarkdownIn: markdownFields,
-				}
-				attachments = append(attachments, *attachment)
+				items = append(items, pullRequestItem(pullRequest))
 			}
 		} else {
-			attachment := &Attachment{
+			item := &ResultItem{
 				Text:  "No active repositories have any open pull requests.",
 				Color: "#A0A0A0",
 			}
-			attachments = append(attachments, *attachment)
+			items = append(items, *item)
 		}
 	} else {
-		attachment := &Attachment{
+		item := &ResultItem{
+			Text:  "Error: " + err.Error(),
+			Color: "#ff0000",
+		}
+		items = append(items, *item)
+	}
+
+	return items
+}
+
+func pullRequestItem(pullRequest github.PullRequest) ResultItem {
+	var numberOfDays = AgeInDays(*pullRequest.CreatedAt)
+	var colour = colorForPullRequestAge(numberOfDays)
+
+	var assigned string
+	if pullRequest.User != nil {
+		assigned = "_" + *pullRequest.User.Login + "_"
+	} else {
+		assigned = "_Unassigned_"
+	}
+
+	var title string = "PR #" + strconv.Itoa(*pullRequest.Number) + " - " + *pullRequest.Title
+	var description string = "*" + strconv.FormatFloat(numberOfDays, 'f', 0, 64) + " " + DayUnit() + " in " + *pullRequest.Head.Repo.Name + "* created by " + assigned
+	return ResultItem{
+		Title:     title,
+		TitleLink: *pullRequest.HTMLURL,
+		Text:      description,
+		Color:     colour,
+	}
+}
+
+// colorForPullRequestAge sets up a color gradient to quickly show PR age for someone scanning the list.
+func colorForPullRequestAge(numberOfDays float64) string {
+	if numberOfDays > 30 {
+		return "#ff1010"
+	} else if numberOfDays > 7 {
+		return "#ff5757"
+	} else if numberOfDays > 3 {
+		return "#ff9f9f"
+	} else {
+		return "#ffe7e7"
+	}
+}
+
+// BuildPullRequestStatusItems lists pull requests like BuildPullRequestItems and adds
+// who they are waiting on, their commit status and whether they can be merged.
+func BuildPullRequestStatusItems(statuses []githubservice.PullRequestStatus, err error) []ResultItem {
+	var items []ResultItem
+
+	if err != nil {
+		return append(items, ResultItem{
 			Text:  "Error: " + err.Error(),
 			Color: "#ff0000",
+		})
+	}
+	if len(statuses) == 0 {
+		return append(items, ResultItem{
+			Text:  "No active repositories have any matching pull requests.",
+			Color: "#A0A0A0",
+		})
+	}
+
+	for _, status := range statuses {
+		item := pullRequestItem(status.PullRequest)
+		if status.Err != nil {
+			item.Fields = []ResultField{{Title: "Status", Value: "Couldn't load its reviews and checks: " + status.Err.Error()}}
+			items = append(items, item)
+			continue
+		}
+		item.Fields = []ResultField{
+			{Title: "Review", Value: describeReviews(status), Short: true},
+			{Title: "Status", Value: describeChecks(status), Short: true},
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// BuildPullRequestStatusItemsByRepo groups pull requests by repository, each group
+// starting with a subtotal of how many are waiting on review, failing or conflicting.
+func BuildPullRequestStatusItemsByRepo(statuses []githubservice.PullRequestStatus, err error) []ResultItem {
+	if err != nil || len(statuses) == 0 {
+		return BuildPullRequestStatusItems(statuses, err)
+	}
+
+	byRepo := make(map[string][]githubservice.PullRequestStatus)
+	var repos []string
+	for _, status := range statuses {
+		repo := *status.PullRequest.Base.Repo.Name
+		if _, ok := byRepo[repo]; !ok {
+			repos = append(repos, repo)
+		}
+		byRepo[repo] = append(byRepo[repo], status)
+	}
+	sort.Sort(CaseInsensitiveSorter(repos))
+
+	var items []ResultItem
+	for _, repo := range repos {
+		items = append(items, ResultItem{
+			Title: repo,
+			Text:  describePullRequestSubtotal(byRepo[repo]),
+			Color: "#439FE0",
+		})
+		items = append(items, BuildPullRequestStatusItems(byRepo[repo], nil)...)
+	}
+	return items
+}
+
+// describePullRequestSubtotal counts the pull requests waiting on something. Those
+// whose status couldn't be loaded are counted apart, as their reviews and checks are unknown.
+func describePullRequestSubtotal(statuses []githubservice.PullRequestStatus) string {
+	var needsReview, failing, conflicts, unknown int
+	for _, status := range statuses {
+		if status.Err != nil {
+			unknown++
+			continue
+		}
+		if status.NeedsReview() {
+			needsReview++
+		}
+		if status.Failing() {
+			failing++
+		}
+		if status.HasConflicts() {
+			conflicts++
 		}
-		attachments = append(attachments, *attachment)
 	}
+	text := "*" + strconv.Itoa(len(statuses)) + " " + pluralize(len(statuses), "pull request", "pull requests") + "*: " +
+		strconv.Itoa(needsReview) + " waiting on review, " + strconv.Itoa(failing) + " failing, " + strconv.Itoa(conflicts) + " with conflicts"
+	if unknown > 0 {
+		text += ", " + strconv.Itoa(unknown) + " couldn't be checked"
+	}
+	return text
+}
 
-	return attachments
+func describeReviews(status githubservice.PullRequestStatus) string {
+	var lines []string
+	if approved := status.ReviewersWithState(githubservice.ReviewApproved); len(approved) > 0 {
+		lines = append(lines, ":white_check_mark: Approved by "+strings.Join(approved, ", "))
+	}
+	if changes := status.ReviewersWithState(githubservice.ReviewChangesRequested); len(changes) > 0 {
+		line := ":x: Changes requested by " + strings.Join(changes, ", ")
+		if len(status.RequestedReviewers) == 0 {
+			line += ", waiting on the author"
+		}
+		lines = append(lines, line)
+	}
+	if len(status.RequestedReviewers) > 0 {
+		lines = append(lines, ":hourglass: Waiting on "+strings.Join(status.RequestedReviewers, ", "))
+	} else if status.NeedsReview() {
+		lines = append(lines, ":hourglass: Needs a reviewer")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func describeChecks(status githubservice.PullRequestStatus) string {
+	var checks string
+	switch status.CheckState {
+	case "success":
+		checks = ":white_check_mark: Checks passing"
+	case "failure", "error":
+		checks = ":x: Checks failing"
+	case "pending":
+		checks = ":hourglass: Checks running"
+	default:
+		checks = "No checks"
+	}
+
+	if status.HasConflicts() {
+		return checks + "\n:x: Has conflicts"
+	} else if status.Mergeable == nil {
+		return checks + "\nMergeability unknown"
+	} else if status.MergeableState == "blocked" {
+		return checks + "\n:no_entry: Blocked by branch protection"
+	}
+	return checks + "\n:white_check_mark: Mergeable"
 }
 
-func BuildAttachmentsShowCommits(repos map[string][]github.RepositoryCommit, err error) []Attachment {
-	var attachments []Attachment
+func BuildCommitItems(repos map[string]githubservice.BranchCommits, err error) []ResultItem {
+	var items []ResultItem
 	sortedRepoNames := make([]string, len(repos))
 
 	for repoName := range repos {
@@ -266,7 +585,7 @@ func BuildAttachmentsShowCommits(repos map[string][]github.RepositoryCommit, err
 
 	if err == nil {
 		for _, repoName := range sortedRepoNames {
-			repoCommits := repos[repoName]
+			repoCommits := repos[repoName].Commits
 
 			if len(repoCommits) > 0 {
 				for _, commit := range repoCommits {
@@ -279,31 +598,29 @@ func BuildAttachmentsShowCommits(repos map[string][]github.RepositoryCommit, err
 
 					var title string = repoName + "/" + (*commit.SHA)[0:7] + " - " + *commit.Commit.Message
 					var description string = commit.Commit.Author.Date.Format("January _2 3:04PM") + " by " + author
-					markdownFields := []MarkdownField{MarkdownFieldTitle, MarkdownFieldText}
-					attachment := &Attachment{
-						Title:      title,
-						TitleLink:  *commit.HTMLURL,
-						Text:       description,
-						Color:      "#ff1010",
-						MarkdownIn: markdownFields,
+					item := &ResultItem{
+						Title:     title,
+						TitleLink: *commit.HTMLURL,
+						Text:      description,
+						Color:     "#ff1010",
 					}
-					attachments = append(attachments, *attachment)
+					items = append(items, *item)
 				}
 			}
 		}
 	} else {
-		attachment := &Attachment{
+		item := &ResultItem{
 			Text:  "Error: " + err.Error(),
 			Color: "#ff0000",
 		}
-		attachments = append(attachments, *attachment)
+		items = append(items, *item)
 	}
 
-	return attachments
+	return items
 }
 
-func BuildAttachmentCommitSummaryByRepo(reposToCommits map[string][]github.RepositoryCommit, owner string, days int) []Attachment {
-	var attachments []Attachment
+func BuildCommitSummaryItemsByRepo(reposToCommits map[string]githubservice.BranchCommits, owner string, days int) []ResultItem {
+	var items []ResultItem
 
 	//Sort list by RepoName
 	repos := make([]string, 0, len(reposToCommits))
@@ -314,7 +631,8 @@ func BuildAttachmentCommitSummaryByRepo(reposToCommits map[string][]github.Repos
 
 	for _, repoName := range repos {
 		var commitList []string
-		commitsToMaster := reposToCommits[repoName]
+		branch := reposToCommits[repoName].Branch
+		commitsToMaster := reposToCommits[repoName].Commits
 
 		for _, commit := range commitsToMaster {
 			commitList = append(commitList, (*commit.SHA)[0:7])
@@ -324,17 +642,16 @@ func BuildAttachmentCommitSummaryByRepo(reposToCommits map[string][]github.Repos
 		if len(commitList) == 1 {
 			commitWording = "commit"
 		}
-		attachment := &Attachment{
-			Title:      repoName,
-			TitleLink:  "https://www.github.com/" + owner + "/" + repoName + "/commits/master",
-			Text:       strconv.Itoa(len(commitList)) + " " + commitWording + ": " + commitListString,
-			Color:      colorForMasterCommitCount(len(commitList)),
-			MarkdownIn: []MarkdownField{MarkdownFieldText},
+		item := &ResultItem{
+			Title:     repoName + " (" + branch + ")",
+			TitleLink: "https://www.github.com/" + owner + "/" + repoName + "/commits/" + branch,
+			Text:      strconv.Itoa(len(commitList)) + " " + commitWording + ": " + commitListString,
+			Color:     colorForMasterCommitCount(len(commitList)),
 		}
-		attachments = append(attachments, *attachment)
+		items = append(items, *item)
 	}
 
-	return attachments
+	return items
 }
 
 func colorForMasterCommitCount(commitCount int) string {
@@ -0,0 +1,71 @@
+package robots
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/RobotsAndPencils/marvin/githubservice"
+	. "github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+// The robots read their configuration from the environment or config.json while the
+// package initializes. Package variables are set up before any init function runs,
+// so this gives them an empty configuration that tests fill in as they need.
+var _ = useTestConfiguration()
+
+func useTestConfiguration() bool {
+	os.Setenv("MARVIN_CONFIG", "{}")
+	os.Setenv("GITHUB_CONFIG", "{}")
+	return true
+}
+
+func TestShared(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Arguments", func() {
+		g.It("Should split arguments from flags with values of several words", func() {
+			args, flags := ParseArguments("marvin bug --lane ready for qa --Assignee bob --mine")
+
+			Expect(args).To(Equal([]string{"marvin", "bug"}))
+			Expect(flags).To(Equal(map[string]string{"lane": "ready for qa", "assignee": "bob", "mine": ""}))
+		})
+
+		g.It("Should treat a lone -- as an argument", func() {
+			args, flags := ParseArguments("-- title")
+
+			Expect(args).To(Equal([]string{"--", "title"}))
+			Expect(flags).To(BeEmpty())
+		})
+	})
+
+	g.Describe("Days", func() {
+		g.It("Should read days and weeks", func() {
+			Expect(ParseDays("30d")).To(Equal(30))
+			Expect(ParseDays(" 6W ")).To(Equal(42))
+			Expect(ParseDays("14")).To(Equal(14))
+		})
+
+		g.It("Should refuse periods that aren't a positive number of days", func() {
+			for _, period := range []string{"", "d", "0d", "-3", "two weeks", "3m"} {
+				_, err := ParseDays(period)
+				Expect(err).To(HaveOccurred())
+			}
+		})
+	})
+
+	g.Describe("Pull request subtotals", func() {
+		g.It("Should leave pull requests that couldn't be checked out of the counts", func() {
+			statuses := []githubservice.PullRequestStatus{
+				{CheckState: "failure"},
+				{Err: errors.New("rate limited")},
+			}
+
+			Expect(describePullRequestSubtotal(statuses)).To(Equal("*2 pull requests*: 1 waiting on review, 1 failing, 0 with conflicts, 1 couldn't be checked"))
+		})
+	})
+}
@@ -0,0 +1,263 @@
+package robots
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RobotsAndPencils/marvin/githubservice"
+	"github.com/kelseyhightower/envconfig"
+)
+
+type StaleBot struct {
+}
+
+var StaleConfig = new(GithubConfiguration)
+
+// Loads the config file and registers the bot with the server for command /stale.
+func init() {
+	// Try to load the configuration from the environment and fall back to files in the filesystem
+	var c ConfigSpecification
+	err := envconfig.Process("github", &c)
+
+	if err != nil {
+		log.Println(err.Error())
+
+		// Fall back to reading from files if there is an error
+		loadStaleConfigFromFile()
+	} else {
+		err = json.Unmarshal([]byte(c.Config), StaleConfig)
+		if err != nil {
+			log.Println("error parsing config: ", err)
+			loadStaleConfigFromFile()
+		}
+	}
+	Stale := &StaleBot{}
+	RegisterRobot("stale", Stale)
+
+	for _, report := range StaleConfig.StaleReports {
+		report := report
+		RegisterJob("stale-"+report.Channel+"-"+report.Repo, report.At, func(now time.Time) {
+			if !isWorkday(now) {
+				return
+			}
+			SendStaleReport(report.Channel, report.Repo, staleDays(), report.Mention, nil)
+		})
+	}
+}
+
+func loadStaleConfigFromFile() {
+	flag.Parse()
+	configFile := filepath.Join(*ConfigDirectory, "github.json")
+	if _, err := os.Stat(configFile); err == nil {
+		config, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			log.Printf("ERROR: Error opening github config: %s", err)
+			return
+		}
+		err = json.Unmarshal(config, StaleConfig)
+		if err != nil {
+			log.Printf("ERROR: Error parsing github config: %s", err)
+			return
+		}
+	} else {
+		log.Printf("WARNING: Could not find configuration file github.json in %s", *ConfigDirectory)
+	}
+}
+
+func (r StaleBot) parsePayload(p *Payload) (repo string, days int, mention bool, err error) {
+	args, flags := ParseArguments(p.Text)
+	if len(args) != 1 {
+		return "", 0, false, errors.New("Usage: /stale repo|* [--days 14] [--mention]")
+	}
+
+	_, mention = flags["mention"]
+	days = staleDays()
+	if value, ok := flags["days"]; ok {
+		days, err = ParseDays(value)
+	}
+	return args[0], days, mention, err
+}
+
+// staleDays is how many days without activity make work stale, unless a command says otherwise.
+func staleDays() int {
+	if StaleConfig.StaleDays > 0 {
+		return StaleConfig.StaleDays
+	}
+	return 14
+}
+
+// All Robots must implement a Run command to be executed when the registered command is received.
+func (r StaleBot) Run(p *Payload) string {
+	repo, days, _, err := r.parsePayload(p)
+	if err != nil {
+		return err.Error()
+	}
+
+	// If you (optionally) want to do some asynchronous work (like sending API calls to slack)
+	// you can put it in a go routine like this
+	go r.DeferredAction(p)
+	// The string returned here will be shown only to the user who executed the command
+	// and will show up as a message from slackbot.
+
+	return "Looking for work in " + repo + " without activity for " + strconv.Itoa(days) + " " + DayUnit() + " or over its lane's limit..."
+}
+
+func (r StaleBot) DeferredAction(p *Payload) {
+	repo, days, mention, _ := r.parsePayload(p)
+	SendStaleReport(p.ChannelID, repo, days, mention, []ResultAction{RefreshButton(p.Robot, p.Text)})
+}
+
+// SendStaleReport posts the stale issues and pull requests of repo, or of every
+// active repo for "*", to channel.
+func SendStaleReport(channel string, repo string, days int, mention bool, actions []ResultAction) {
+	service := NewGithubService(StaleConfig)
+
+	repos := []string{repo}
+	var err error
+	if repo == "*" {
+		repos, err = service.ActiveRepos(StaleConfig.Owner, 30)
+	}
+
+	var stale []githubservice.StaleItem
+	for _, name := range repos {
+		if err != nil {
+			break
+		}
+		var found []githubservice.StaleItem
+		found, err = service.StaleWork(StaleConfig.Owner, name, days, time.Now())
+		stale = append(stale, found...)
+	}
+
+	var items []ResultItem
+	if err != nil {
+		items = append(items, ResultItem{
+			Text:  "Error: " + err.Error(),
+			Color: "#ff0000",
+		})
+	} else {
+		items = BuildStaleItems(stale, BoardLanes(), mention)
+	}
+
+	text := "Stale work in *" + repo + "*"
+	if repo == "*" {
+		text = "Stale work in all repos"
+	}
+
+	// Let's describe the response with the Result struct defined in result.go and send it as an
+	// IncomingWebhook message to slack that can be seen by everyone in the room. You can
+	// read the Slack API Docs (https://api.slack.com/) to know which fields are required, etc.
+	// You can also see what data is available from the command structure in definitions.go
+	result := Result{
+		Text:    text + ": nothing happened for " + strconv.Itoa(days) + " " + DayUnit() + " or it is over its lane's limit (" + strconv.Itoa(len(stale)) + ")",
+		Items:   items,
+		Actions: actions,
+	}
+
+	SendResult(channel, result)
+}
+
+// BuildStaleItems lists stale issues by lane, in board order, followed by stale pull
+// requests. Each list names the longest idle first and sums up the rest. Lanes with
+// work over their SLA are red.
+func BuildStaleItems(stale []githubservice.StaleItem, lanes []githubservice.Lane, mention bool) []ResultItem {
+	var items []ResultItem
+
+	byLane := make(map[string][]githubservice.StaleItem)
+	var pullRequests []githubservice.StaleItem
+	for _, item := range stale {
+		if item.IsPullRequest() {
+			pullRequests = append(pullRequests, item)
+		} else {
+			byLane[item.Lane] = append(byLane[item.Lane], item)
+		}
+	}
+
+	laneNames := []string{}
+	for _, lane := range lanes {
+		laneNames = append(laneNames, lane.Name)
+	}
+	// Without a catch-all lane some issues are in no lane at all.
+	laneNames = append(laneNames, "")
+
+	for _, name := range laneNames {
+		if len(byLane[name]) == 0 {
+			continue
+		}
+		title := name
+		if title == "" {
+			title = laneName(nil)
+		}
+		color := "#ff9f9f"
+		var lines []string
+		for _, item := range byLane[name] {
+			if item.BreachedSLA {
+				color = "#ff1010"
+			}
+			lines = append(lines, describeStaleItem(item, mention))
+		}
+		items = append(items, ResultItem{
+			Title: title + " (" + strconv.Itoa(len(lines)) + ")",
+			Text:  strings.Join(limitList(lines), "\n"),
+			Color: color,
+		})
+	}
+
+	if len(pullRequests) > 0 {
+		var lines []string
+		for _, item := range pullRequests {
+			lines = append(lines, describeStaleItem(item, mention))
+		}
+		items = append(items, ResultItem{
+			Title: "Pull requests (" + strconv.Itoa(len(lines)) + ")",
+			Text:  strings.Join(limitList(lines), "\n"),
+			Color: "#ff9f9f",
+		})
+	}
+
+	if len(items) == 0 {
+		items = append(items, ResultItem{
+			Text:  "Nothing is stale",
+			Color: "#A0A0A0",
+		})
+	}
+	return items
+}
+
+func describeStaleItem(item githubservice.StaleItem, mention bool) string {
+	line := issueLine(item.Issue) + " _idle " + strconv.FormatFloat(item.IdleDays, 'f', 0, 64) + " " + DayUnit()
+	if item.BreachedSLA {
+		line += ", " + strconv.FormatFloat(item.LaneDays, 'f', 0, 64) + " in " + item.Lane + " where the limit is " + strconv.Itoa(item.SLA)
+	}
+	line += "_"
+
+	if mention {
+		var login string
+		if item.Issue.Assignee != nil && item.Issue.Assignee.Login != nil {
+			login = *item.Issue.Assignee.Login
+		} else if item.IsPullRequest() && item.Issue.User != nil && item.Issue.User.Login != nil {
+			// Nobody is assigned to most pull requests, so their author hears about it.
+			login = *item.Issue.User.Login
+		}
+		if who := SlackMention(login); who != "" {
+			line += " " + who
+		} else if login != "" {
+			line += " " + login
+		}
+	}
+	return line
+}
+
+func (r StaleBot) Description() (description string) {
+	// In addition to a Run method, each Robot must implement a Description method which
+	// is just a simple string describing what the Robot does. This is used in the included
+	// /c command which gives users a list of commands and descriptions
+	return "This is a description for StaleBot which will be displayed on /c"
+}
@@ -0,0 +1,59 @@
+package robots
+
+import (
+	"strings"
+)
+
+type SubscribeBot struct {
+}
+
+// Registers the bot with the server for command /subscribe.
+func init() {
+	Subscribe := &SubscribeBot{}
+	RegisterRobot("subscribe", Subscribe)
+}
+
+// All Robots must implement a Run command to be executed when the registered command is received.
+func (r SubscribeBot) Run(p *Payload) string {
+	words := strings.Fields(p.Text)
+	if len(words) == 0 {
+		return describeSubscriptions(p.ChannelID)
+	}
+
+	kinds, err := ParseSubscriptionKinds(words[1:])
+	if err != nil {
+		return err.Error()
+	}
+	err = Subscribe(p.ChannelID, words[0], kinds)
+	if err != nil {
+		return "Couldn't subscribe: " + err.Error()
+	}
+
+	// There is nothing slow to do here, so the answer is returned straight away and
+	// will be shown only to the user who executed the command.
+	return describeSubscriptions(p.ChannelID)
+}
+
+func describeSubscriptions(channel string) string {
+	subscriptions := ChannelSubscriptions(channel)
+	if len(subscriptions) == 0 {
+		return "This channel isn't subscribed to anything. Try /subscribe repo [" + strings.Join(SubscriptionKinds, "|") + "]"
+	}
+
+	var lines []string
+	for _, subscription := range subscriptions {
+		kinds := "everything"
+		if len(subscription.Events) > 0 {
+			kinds = strings.Join(subscription.Events, ", ")
+		}
+		lines = append(lines, "• "+subscription.Repo+": "+kinds)
+	}
+	return "This channel is subscribed to:\n" + strings.Join(lines, "\n")
+}
+
+func (r SubscribeBot) Description() (description string) {
+	// In addition to a Run method, each Robot must implement a Description method which
+	// is just a simple string describing what the Robot does. This is used in the included
+	// /c command which gives users a list of commands and descriptions
+	return "This is a description for SubscribeBot which will be displayed on /c"
+}
@@ -0,0 +1,172 @@
+package robots
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"sync"
+)
+
+// The kinds of GitHub events a channel can subscribe to.
+const (
+	SubscribeIssues = "issues"
+	SubscribePRs    = "prs"
+	SubscribePushes = "pushes"
+	SubscribeLanes  = "lanes"
+
+	// Pushes straight to a repository's default branch that skipped a pull request.
+	SubscribeDirectPushes = "directpushes"
+
+	subscriptionsBucket = "subscriptions"
+)
+
+// Subscriptions are changed by reading a channel's list and writing it back, so
+// two commands at once could otherwise lose one of the changes.
+var subscriptionsMutex sync.Mutex
+
+var SubscriptionKinds = []string{SubscribeIssues, SubscribePRs, SubscribePushes, SubscribeLanes, SubscribeDirectPushes}
+
+// Subscription sends the events of a repository to a channel. A repo of "*"
+// subscribes to every repository and no events means every kind of event.
+type Subscription struct {
+	Repo    string   `json:"repo"`
+	Channel string   `json:"channel"`
+	Events  []string `json:"events"`
+}
+
+func (s Subscription) Matches(repo string, event string) bool {
+	if s.Repo != "*" && !strings.EqualFold(s.Repo, repo) {
+		return false
+	}
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, e := range s.Events {
+		if strings.EqualFold(e, event) {
+			return true
+		}
+	}
+	return false
+}
+
+// SubscribedChannels lists the channels that want to hear about an event in repo,
+// from both the configuration and the subscriptions made with /subscribe.
+func SubscribedChannels(repo string, event string) []string {
+	subscriptions := append([]Subscription{}, Config.Subscriptions...)
+	for _, channel := range DataStore().Keys(subscriptionsBucket) {
+		subscriptions = append(subscriptions, ChannelSubscriptions(channel)...)
+	}
+
+	var channels []string
+	seen := make(map[string]bool)
+	for _, subscription := range subscriptions {
+		if subscription.Matches(repo, event) && !seen[subscription.Channel] {
+			seen[subscription.Channel] = true
+			channels = append(channels, subscription.Channel)
+		}
+	}
+	return channels
+}
+
+// ChannelSubscriptions lists the subscriptions a channel made with /subscribe.
+func ChannelSubscriptions(channel string) []Subscription {
+	var subscriptions []Subscription
+	_, err := DataStore().Get(subscriptionsBucket, channel, &subscriptions)
+	if err != nil {
+		log.Printf("ERROR: Couldn't load subscriptions for %s: %s", channel, err)
+	}
+	return subscriptions
+}
+
+// Subscribe adds events of repo to what channel hears about. No events means all of them.
+func Subscribe(channel string, repo string, events []string) error {
+	subscriptionsMutex.Lock()
+	defer subscriptionsMutex.Unlock()
+
+	subscriptions := ChannelSubscriptions(channel)
+
+	for i := range subscriptions {
+		if strings.EqualFold(subscriptions[i].Repo, repo) {
+			if len(events) == 0 || len(subscriptions[i].Events) == 0 {
+				subscriptions[i].Events = nil
+			} else {
+				subscriptions[i].Events = union(subscriptions[i].Events, events)
+			}
+			return DataStore().Put(subscriptionsBucket, channel, subscriptions)
+		}
+	}
+
+	subscriptions = append(subscriptions, Subscription{Repo: repo, Channel: channel, Events: events})
+	return DataStore().Put(subscriptionsBucket, channel, subscriptions)
+}
+
+// Unsubscribe stops channel hearing about events of repo. No events means all of them.
+func Unsubscribe(channel string, repo string, events []string) error {
+	subscriptionsMutex.Lock()
+	defer subscriptionsMutex.Unlock()
+
+	var remaining []Subscription
+
+	for _, subscription := range ChannelSubscriptions(channel) {
+		if strings.EqualFold(subscription.Repo, repo) && len(events) > 0 {
+			kinds := subscription.Events
+			if len(kinds) == 0 {
+				kinds = SubscriptionKinds
+			}
+			subscription.Events = difference(kinds, events)
+			if len(subscription.Events) == 0 {
+				continue
+			}
+		} else if strings.EqualFold(subscription.Repo, repo) {
+			continue
+		}
+		remaining = append(remaining, subscription)
+	}
+
+	if len(remaining) == 0 {
+		return DataStore().Delete(subscriptionsBucket, channel)
+	}
+	return DataStore().Put(subscriptionsBucket, channel, remaining)
+}
+
+// ParseSubscriptionKinds checks that every word names a kind of event.
+func ParseSubscriptionKinds(words []string) ([]string, error) {
+	var kinds []string
+	for _, word := range words {
+		kind := strings.ToLower(word)
+		if !contains(SubscriptionKinds, kind) {
+			return nil, errors.New("I can't subscribe to " + word + ", try " + strings.Join(SubscriptionKinds, ", "))
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
+
+func union(a []string, b []string) []string {
+	result := append([]string{}, a...)
+	for _, s := range b {
+		if !contains(result, s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func difference(a []string, b []string) []string {
+	var result []string
+	for _, s := range a {
+		if !contains(b, s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
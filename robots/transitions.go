@@ -0,0 +1,149 @@
+package robots
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RobotsAndPencils/marvin/githubservice"
+	"github.com/google/go-github/github"
+)
+
+// Waffle and /move change lanes by removing the old lane's label and then adding the
+// new one, and GitHub delivers those as two events. An issue that drops into the
+// catch-all lane is held this long to see whether a new lane label follows, so the
+// channel hears about one move instead of two.
+const laneTransitionWait = 10 * time.Second
+
+// LaneTransition is an issue moving from one lane to another.
+type LaneTransition struct {
+	Repo   string
+	Issue  github.Issue
+	From   githubservice.Lane
+	To     githubservice.Lane
+	Sender *github.User
+}
+
+type pendingTransition struct {
+	from  githubservice.Lane
+	timer *time.Timer
+}
+
+var pendingTransitions = make(map[string]*pendingTransition)
+var pendingTransitionsMutex sync.Mutex
+
+// What to do with a lane transition once it is merged with one held back for the
+// same issue.
+const (
+	transitionIgnore = iota
+	transitionHold
+	transitionReport
+)
+
+// handleLaneTransition reports a labeled or unlabeled issue that changed lanes, holding
+// a drop into the catch-all lane back for a while in case a new lane label follows.
+func handleLaneTransition(event *github.IssuesEvent) {
+	transition := laneTransitionForEvent(BoardLanes(), event)
+	if transition == nil {
+		return
+	}
+	key := transition.Repo + "#" + strconv.Itoa(*transition.Issue.Number)
+
+	pendingTransitionsMutex.Lock()
+	defer pendingTransitionsMutex.Unlock()
+
+	var held *githubservice.Lane
+	if pending, ok := pendingTransitions[key]; ok {
+		pending.timer.Stop()
+		delete(pendingTransitions, key)
+		held = &pending.from
+	}
+
+	merged, decision := decideLaneTransition(*transition, held, *event.Action)
+	switch decision {
+	case transitionHold:
+		pendingTransitions[key] = &pendingTransition{
+			from: merged.From,
+			timer: time.AfterFunc(laneTransitionWait, func() {
+				pendingTransitionsMutex.Lock()
+				delete(pendingTransitions, key)
+				pendingTransitionsMutex.Unlock()
+				reportLaneTransition(merged)
+			}),
+		}
+	case transitionReport:
+		go reportLaneTransition(merged)
+	}
+}
+
+// laneTransitionForEvent works out which lane a labeled or unlabeled issue was in
+// before the change and which it is in now. It is nil when the event isn't about a
+// label or the issue isn't in a lane.
+func laneTransitionForEvent(lanes []githubservice.Lane, event *github.IssuesEvent) *LaneTransition {
+	if event.Label == nil || event.Label.Name == nil {
+		return nil
+	}
+
+	before := *event.Issue
+	before.Labels = nil
+	for _, label := range event.Issue.Labels {
+		if !strings.EqualFold(*label.Name, *event.Label.Name) {
+			before.Labels = append(before.Labels, label)
+		}
+	}
+	if *event.Action == "unlabeled" {
+		before.Labels = append(before.Labels, *event.Label)
+	}
+
+	fromLane := githubservice.LaneForIssue(lanes, before)
+	toLane := githubservice.LaneForIssue(lanes, *event.Issue)
+	if fromLane == nil || toLane == nil {
+		return nil
+	}
+	return &LaneTransition{
+		Repo:   *event.Repo.Name,
+		Issue:  *event.Issue,
+		From:   *fromLane,
+		To:     *toLane,
+		Sender: event.Sender,
+	}
+}
+
+// decideLaneTransition starts a transition from the lane held back for the issue, if
+// there is one, and decides whether to report it, hold it back or ignore it because
+// the issue ended up where it started.
+func decideLaneTransition(transition LaneTransition, held *githubservice.Lane, action string) (LaneTransition, int) {
+	if held != nil {
+		transition.From = *held
+	}
+	if transition.From.Name == transition.To.Name {
+		return transition, transitionIgnore
+	}
+	if action == "unlabeled" && transition.To.IsCatchAll() {
+		return transition, transitionHold
+	}
+	return transition, transitionReport
+}
+
+func reportLaneTransition(transition LaneTransition) {
+	issue := transition.Issue
+	text := "#" + strconv.Itoa(*issue.Number) + " moved " + transition.From.Name + " → " + transition.To.Name + " by @" + userLogin(transition.Sender)
+
+	result := Result{
+		Text: "[" + transition.Repo + "] " + text,
+		Items: []ResultItem{{
+			Title:     "Issue #" + strconv.Itoa(*issue.Number) + ", " + *issue.Title,
+			TitleLink: *issue.HTMLURL,
+			Text:      "*" + transition.From.Name + "* → *" + transition.To.Name + "*",
+			Color:     "#439FE0",
+		}},
+	}
+	notify(transition.Repo, SubscribeLanes, result)
+
+	// Moving an issue into a lane is what can break its limits.
+	limits := RepoWIPLimits(transition.Repo)
+	if githubservice.LaneWIPLimit(transition.To, limits) > 0 || githubservice.LaneAssigneeWIPLimit(transition.To, limits) > 0 {
+		CheckWIPLimits(transition.Repo, transition.To.Name)
+	}
+}
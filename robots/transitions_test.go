@@ -0,0 +1,79 @@
+package robots
+
+import (
+	"testing"
+
+	"github.com/RobotsAndPencils/marvin/githubservice"
+	. "github.com/franela/goblin"
+	"github.com/google/go-github/github"
+	. "github.com/onsi/gomega"
+)
+
+func labelEvent(action string, label string, labels ...string) *github.IssuesEvent {
+	repo := "marvin"
+	number := 7
+	issue := &github.Issue{Number: &number}
+	for i := range labels {
+		issue.Labels = append(issue.Labels, github.Label{Name: &labels[i]})
+	}
+	return &github.IssuesEvent{
+		Action: &action,
+		Issue:  issue,
+		Label:  &github.Label{Name: &label},
+		Repo:   &github.Repository{Name: &repo},
+	}
+}
+
+func TestTransitions(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	lanes := githubservice.DefaultLanes
+
+	g.Describe("Lane transitions", func() {
+		g.It("Should report a label that moves an issue to another lane", func() {
+			transition := laneTransitionForEvent(lanes, labelEvent("labeled", "in progress", "in progress"))
+			Expect(transition).NotTo(BeNil())
+
+			merged, decision := decideLaneTransition(*transition, nil, "labeled")
+			Expect(decision).To(Equal(transitionReport))
+			Expect(merged.From.Name).To(Equal("Backlog"))
+			Expect(merged.To.Name).To(Equal("In Progress"))
+		})
+
+		g.It("Should merge a removed lane label with the one added within the wait", func() {
+			removed := laneTransitionForEvent(lanes, labelEvent("unlabeled", "sprint"))
+			Expect(removed).NotTo(BeNil())
+
+			held, decision := decideLaneTransition(*removed, nil, "unlabeled")
+			Expect(decision).To(Equal(transitionHold))
+			Expect(held.From.Name).To(Equal("Sprint"))
+			Expect(held.To.Name).To(Equal("Backlog"))
+
+			added := laneTransitionForEvent(lanes, labelEvent("labeled", "in progress", "in progress"))
+			merged, decision := decideLaneTransition(*added, &held.From, "labeled")
+			Expect(decision).To(Equal(transitionReport))
+			Expect(merged.From.Name).To(Equal("Sprint"))
+			Expect(merged.To.Name).To(Equal("In Progress"))
+		})
+
+		g.It("Should ignore a lane label that is removed and added back within the wait", func() {
+			removed := laneTransitionForEvent(lanes, labelEvent("unlabeled", "sprint"))
+			held, _ := decideLaneTransition(*removed, nil, "unlabeled")
+
+			added := laneTransitionForEvent(lanes, labelEvent("labeled", "sprint", "sprint"))
+			_, decision := decideLaneTransition(*added, &held.From, "labeled")
+			Expect(decision).To(Equal(transitionIgnore))
+		})
+
+		g.It("Should ignore labels that aren't lanes", func() {
+			transition := laneTransitionForEvent(lanes, labelEvent("labeled", "bug", "bug", "sprint"))
+			Expect(transition).NotTo(BeNil())
+
+			_, decision := decideLaneTransition(*transition, nil, "labeled")
+			Expect(decision).To(Equal(transitionIgnore))
+		})
+	})
+}
@@ -0,0 +1,42 @@
+package robots
+
+import (
+	"strings"
+)
+
+type UnsubscribeBot struct {
+}
+
+// Registers the bot with the server for command /unsubscribe.
+func init() {
+	Unsubscribe := &UnsubscribeBot{}
+	RegisterRobot("unsubscribe", Unsubscribe)
+}
+
+// All Robots must implement a Run command to be executed when the registered command is received.
+func (r UnsubscribeBot) Run(p *Payload) string {
+	words := strings.Fields(p.Text)
+	if len(words) == 0 {
+		return "Usage: /unsubscribe repo [" + strings.Join(SubscriptionKinds, "|") + "]"
+	}
+
+	kinds, err := ParseSubscriptionKinds(words[1:])
+	if err != nil {
+		return err.Error()
+	}
+	err = Unsubscribe(p.ChannelID, words[0], kinds)
+	if err != nil {
+		return "Couldn't unsubscribe: " + err.Error()
+	}
+
+	// There is nothing slow to do here, so the answer is returned straight away and
+	// will be shown only to the user who executed the command.
+	return describeSubscriptions(p.ChannelID)
+}
+
+func (r UnsubscribeBot) Description() (description string) {
+	// In addition to a Run method, each Robot must implement a Description method which
+	// is just a simple string describing what the Robot does. This is used in the included
+	// /c command which gives users a list of commands and descriptions
+	return "This is a description for UnsubscribeBot which will be displayed on /c"
+}
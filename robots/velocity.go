@@ -0,0 +1,220 @@
+package robots
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RobotsAndPencils/marvin/githubservice"
+	"github.com/kelseyhightower/envconfig"
+)
+
+type VelocityBot struct {
+}
+
+var VelocityConfig = new(GithubConfiguration)
+
+// Loads the config file and registers the bot with the server for command /velocity.
+func init() {
+	// Try to load the configuration from the environment and fall back to files in the filesystem
+	var c ConfigSpecification
+	err := envconfig.Process("github", &c)
+
+	if err != nil {
+		log.Println(err.Error())
+
+		// Fall back to reading from files if there is an error
+		loadVelocityConfigFromFile()
+	} else {
+		err = json.Unmarshal([]byte(c.Config), VelocityConfig)
+		if err != nil {
+			log.Println("error parsing config: ", err)
+			loadVelocityConfigFromFile()
+		}
+	}
+	Velocity := &VelocityBot{}
+	RegisterRobot("velocity", Velocity)
+}
+
+func loadVelocityConfigFromFile() {
+	flag.Parse()
+	configFile := filepath.Join(*ConfigDirectory, "github.json")
+	if _, err := os.Stat(configFile); err == nil {
+		config, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			log.Printf("ERROR: Error opening github config: %s", err)
+			return
+		}
+		err = json.Unmarshal(config, VelocityConfig)
+		if err != nil {
+			log.Printf("ERROR: Error parsing github config: %s", err)
+			return
+		}
+	} else {
+		log.Printf("WARNING: Could not find configuration file github.json in %s", *ConfigDirectory)
+	}
+}
+
+func (r VelocityBot) parsePayload(p *Payload) (repo string, sprints int, err error) {
+	args, flags := ParseArguments(p.Text)
+	if len(args) != 1 {
+		return "", 0, errors.New("Usage: /velocity repo [--sprints 5]")
+	}
+
+	sprints = 5
+	if value, ok := flags["sprints"]; ok {
+		sprints, err = strconv.Atoi(value)
+		if err != nil || sprints <= 0 {
+			return "", 0, errors.New("I need a number of sprints, like --sprints 5")
+		}
+	}
+	return args[0], sprints, nil
+}
+
+// All Robots must implement a Run command to be executed when the registered command is received.
+func (r VelocityBot) Run(p *Payload) string {
+	repo, sprints, err := r.parsePayload(p)
+	if err != nil {
+		return err.Error()
+	}
+
+	// If you (optionally) want to do some asynchronous work (like sending API calls to slack)
+	// you can put it in a go routine like this
+	go r.DeferredAction(p)
+	// The string returned here will be shown only to the user who executed the command
+	// and will show up as a message from slackbot.
+
+	return "Working out the velocity of " + repo + " over the last " + strconv.Itoa(sprints) + " sprints..."
+}
+
+func (r VelocityBot) DeferredAction(p *Payload) {
+
+	repo, sprints, _ := r.parsePayload(p)
+
+	days := VelocityConfig.SprintLength
+	if days <= 0 {
+		days = 14
+	}
+
+	service := NewGithubService(VelocityConfig)
+	iterations, byMilestone, err := service.Iterations(VelocityConfig.Owner, repo, sprints, time.Duration(days)*24*time.Hour, time.Now().In(Location()))
+
+	text := "Velocity of *" + repo + "* over the last " + strconv.Itoa(len(iterations)) + " " + strconv.Itoa(days) + "-day iterations"
+	if byMilestone {
+		text = "Velocity of *" + repo + "* over the last " + strconv.Itoa(len(iterations)) + " sprint milestones"
+	}
+
+	var items []ResultItem
+	if err != nil {
+		items = append(items, ResultItem{
+			Text:  "Error: " + err.Error(),
+			Color: "#ff0000",
+		})
+	} else {
+		items = BuildVelocityItems(iterations)
+	}
+
+	// Let's describe the response with the Result struct defined in result.go and send it as an
+	// IncomingWebhook message to slack that can be seen by everyone in the room. You can
+	// read the Slack API Docs (https://api.slack.com/) to know which fields are required, etc.
+	// You can also see what data is available from the command structure in definitions.go
+	result := Result{
+		Text:    text,
+		Items:   items,
+		Actions: []ResultAction{RefreshButton(p.Robot, p.Text)},
+	}
+
+	SendResult(p.ChannelID, result)
+}
+
+// BuildVelocityItems shows the throughput and velocity of each iteration, oldest
+// first, followed by the trend across the finished ones.
+func BuildVelocityItems(iterations []githubservice.Iteration) []ResultItem {
+	var items []ResultItem
+	var throughputs, velocities []string
+	var totalThroughput int
+	var totalPoints float64
+	var finished int
+	estimates := false
+
+	for _, iteration := range iterations {
+		points, estimated := iteration.Velocity(BoardEstimator())
+		if estimated > 0 {
+			estimates = true
+		}
+
+		name := iteration.Name
+		if name == "" {
+			name = iteration.Start.Format("Jan 2") + " - " + iteration.End.Format("Jan 2")
+		}
+		if iteration.InProgress {
+			name += " (in progress)"
+		} else {
+			finished++
+			totalThroughput += iteration.Throughput()
+			totalPoints += points
+		}
+
+		velocity := formatPoints(points)
+		if unestimated := iteration.Throughput() - estimated; unestimated > 0 && estimated > 0 {
+			velocity += " (" + strconv.Itoa(unestimated) + " closed without an estimate)"
+		}
+
+		throughputs = append(throughputs, strconv.Itoa(iteration.Throughput()))
+		velocities = append(velocities, formatPoints(points))
+		items = append(items, ResultItem{
+			Title: name,
+			Color: "#439FE0",
+			Fields: []ResultField{
+				{Title: "Throughput", Value: strconv.Itoa(iteration.Throughput()) + " " + pluralize(iteration.Throughput(), "issue", "issues"), Short: true},
+				{Title: "Velocity", Value: velocity, Short: true},
+			},
+		})
+	}
+
+	if len(items) == 0 {
+		return append(items, ResultItem{
+			Text:  "No sprints found.",
+			Color: "#A0A0A0",
+		})
+	}
+
+	trend := ResultItem{
+		Title: "Trend",
+		Text:  "Throughput: " + strings.Join(throughputs, " → "),
+		Color: "#36a64f",
+	}
+	if estimates {
+		trend.Text += "\nVelocity: " + strings.Join(velocities, " → ")
+	}
+	if finished > 0 {
+		trend.Text += "\nAverage of finished sprints: " + strconv.FormatFloat(float64(totalThroughput)/float64(finished), 'f', 1, 64) + " issues"
+		if estimates {
+			trend.Text += ", " + formatPoints(totalPoints/float64(finished))
+		}
+	}
+	return append(items, trend)
+}
+
+func formatPoints(points float64) string {
+	rounded := strconv.FormatFloat(points, 'f', 1, 64)
+	rounded = strings.TrimSuffix(rounded, ".0")
+	if rounded == "1" {
+		return "1 point"
+	}
+	return rounded + " points"
+}
+
+func (r VelocityBot) Description() (description string) {
+	// In addition to a Run method, each Robot must implement a Description method which
+	// is just a simple string describing what the Robot does. This is used in the included
+	// /c command which gives users a list of commands and descriptions
+	return "This is a description for VelocityBot which will be displayed on /c"
+}
@@ -0,0 +1,122 @@
+package robots
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RobotsAndPencils/marvin/githubservice"
+)
+
+// LaneWIPLimits hold one repository's limits, keyed by lane name.
+type LaneWIPLimits map[string]githubservice.WIPLimit
+
+// RepoWIPLimits are the limits a repository sets for its lanes, overriding the
+// limits of the lanes themselves.
+func RepoWIPLimits(repo string) LaneWIPLimits {
+	for name, limits := range GithubConfig.WIPLimits {
+		if strings.EqualFold(name, repo) {
+			return limits
+		}
+	}
+	return nil
+}
+
+// repoAssignees loads everyone assigned to the open issues of repo when a lane limits
+// the work of each assignee, as the issues themselves only name the first.
+func repoAssignees(service *githubservice.GithubService, owner string, repo string) map[int][]string {
+	limited := false
+	for _, lane := range BoardLanes() {
+		limited = limited || githubservice.LaneAssigneeWIPLimit(lane, RepoWIPLimits(repo)) > 0
+	}
+	if !limited {
+		return nil
+	}
+
+	assignees, err := service.IssueAssignees(owner, repo)
+	if err != nil {
+		log.Printf("ERROR: Couldn't load the assignees of %s, counting only the first of each issue: %s", repo, err)
+	}
+	return assignees
+}
+
+func hasWIPLimits(config *GithubConfiguration) bool {
+	if len(config.WIPLimits) > 0 {
+		return true
+	}
+	for _, lane := range config.Lanes {
+		if lane.WIP > 0 || lane.AssigneeWIP > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckWIPLimits alerts the channels subscribed to lane changes of repo when lanes
+// are over their work in progress limits. With a lane name only that lane is checked.
+func CheckWIPLimits(repo string, lane string) {
+	service := NewGithubService(GithubConfig)
+	byLane, err := service.IssuesByLane(GithubConfig.Owner, repo)
+	if err != nil {
+		log.Printf("ERROR: Couldn't check the WIP limits of %s: %s", repo, err)
+		return
+	}
+
+	breaches := githubservice.WIPBreaches(BoardLanes(), byLane, RepoWIPLimits(repo), repoAssignees(service, GithubConfig.Owner, repo))
+	if lane != "" {
+		breaches = breachesInLane(breaches, lane)
+	}
+	if len(breaches) == 0 {
+		return
+	}
+
+	notify(repo, SubscribeLanes, Result{
+		Text: "[" + repo + "] :warning: Over the WIP limit",
+		Items: []ResultItem{{
+			Text:  describeWIPBreaches(breaches),
+			Color: "#ff1010",
+		}},
+	})
+}
+
+// CheckAllWIPLimits checks the WIP limits of the flow repositories, or of every
+// repository pushed to in the last 30 days if there is no list.
+func CheckAllWIPLimits(now time.Time) {
+	repos := GithubConfig.FlowRepos
+	if len(repos) == 0 {
+		var err error
+		repos, err = NewGithubService(GithubConfig).ActiveRepos(GithubConfig.Owner, 30)
+		if err != nil {
+			log.Printf("ERROR: Couldn't list repositories to check WIP limits: %s", err)
+			return
+		}
+	}
+
+	for _, repo := range repos {
+		CheckWIPLimits(repo, "")
+	}
+}
+
+func breachesInLane(breaches []githubservice.WIPBreach, lane string) []githubservice.WIPBreach {
+	var inLane []githubservice.WIPBreach
+	for _, breach := range breaches {
+		if breach.Lane == lane {
+			inLane = append(inLane, breach)
+		}
+	}
+	return inLane
+}
+
+func describeWIPBreaches(breaches []githubservice.WIPBreach) string {
+	var lines []string
+	for _, breach := range breaches {
+		count := strconv.Itoa(breach.Count) + " " + pluralize(breach.Count, "issue", "issues")
+		if breach.Assignee != "" {
+			lines = append(lines, ":warning: *"+breach.Lane+"*: "+breach.Assignee+" has "+count+", the limit is "+strconv.Itoa(breach.Limit))
+		} else {
+			lines = append(lines, ":warning: *"+breach.Lane+"* has "+count+", the limit is "+strconv.Itoa(breach.Limit))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
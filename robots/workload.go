@@ -0,0 +1,214 @@
+package robots
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/RobotsAndPencils/marvin/githubservice"
+	"github.com/google/go-github/github"
+	"github.com/kelseyhightower/envconfig"
+)
+
+type WorkloadBot struct {
+}
+
+var WorkloadConfig = new(GithubConfiguration)
+
+// Loads the config file and registers the bot with the server for command /workload.
+func init() {
+	// Try to load the configuration from the environment and fall back to files in the filesystem
+	var c ConfigSpecification
+	err := envconfig.Process("github", &c)
+
+	if err != nil {
+		log.Println(err.Error())
+
+		// Fall back to reading from files if there is an error
+		loadWorkloadConfigFromFile()
+	} else {
+		err = json.Unmarshal([]byte(c.Config), WorkloadConfig)
+		if err != nil {
+			log.Println("error parsing config: ", err)
+			loadWorkloadConfigFromFile()
+		}
+	}
+	Workload := &WorkloadBot{}
+	RegisterRobot("workload", Workload)
+}
+
+func loadWorkloadConfigFromFile() {
+	flag.Parse()
+	configFile := filepath.Join(*ConfigDirectory, "github.json")
+	if _, err := os.Stat(configFile); err == nil {
+		config, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			log.Printf("ERROR: Error opening github config: %s", err)
+			return
+		}
+		err = json.Unmarshal(config, WorkloadConfig)
+		if err != nil {
+			log.Printf("ERROR: Error parsing github config: %s", err)
+			return
+		}
+	} else {
+		log.Printf("WARNING: Could not find configuration file github.json in %s", *ConfigDirectory)
+	}
+}
+
+// All Robots must implement a Run command to be executed when the registered command is received.
+func (r WorkloadBot) Run(p *Payload) string {
+	args, _ := ParseArguments(p.Text)
+	if len(args) > 1 {
+		return "Usage: /workload [team]"
+	}
+
+	// If you (optionally) want to do some asynchronous work (like sending API calls to slack)
+	// you can put it in a go routine like this
+	go r.DeferredAction(p)
+	// The string returned here will be shown only to the user who executed the command
+	// and will show up as a message from slackbot.
+
+	if len(args) == 0 {
+		return "Adding up everyone's workload..."
+	}
+	return "Adding up the workload of " + args[0] + "..."
+}
+
+func (r WorkloadBot) DeferredAction(p *Payload) {
+	args, _ := ParseArguments(p.Text)
+	team := ""
+	if len(args) == 1 {
+		team = args[0]
+	}
+
+	service := NewGithubService(WorkloadConfig)
+
+	var items []ResultItem
+	logins, err := TeamLogins(service, team)
+	var workloads []githubservice.Workload
+	if err == nil {
+		workloads = service.Workloads(WorkloadConfig.Owner, logins)
+	}
+	var unassigned []github.Issue
+	inProgress, laneErr := githubservice.FindLane(BoardLanes(), "in progress")
+	if err == nil && laneErr == nil {
+		unassigned, err = service.UnassignedInLane(WorkloadConfig.Owner, *inProgress)
+	}
+
+	if err != nil {
+		items = append(items, ResultItem{
+			Text:  "Error: " + err.Error(),
+			Color: "#ff0000",
+		})
+	} else {
+		items = BuildWorkloadItems(workloads, unassigned, BoardLanes())
+	}
+
+	text := "Workload of *" + team + "*"
+	if team == "" {
+		text = "Workload of everyone linked to Slack"
+	}
+
+	// Let's describe the response with the Result struct defined in result.go and send it as an
+	// IncomingWebhook message to slack that can be seen by everyone in the room. You can
+	// read the Slack API Docs (https://api.slack.com/) to know which fields are required, etc.
+	// You can also see what data is available from the command structure in definitions.go
+	result := Result{
+		Text:    text + " across all repos",
+		Items:   items,
+		Actions: []ResultAction{RefreshButton(p.Robot, p.Text)},
+	}
+
+	SendResult(p.ChannelID, result)
+}
+
+// TeamLogins lists the members of a team from the teams in the github configuration,
+// or else from the GitHub team with that slug. Without a team it lists everyone
+// linked in the identities of the configuration.
+func TeamLogins(service *githubservice.GithubService, team string) ([]string, error) {
+	var logins []string
+	if team == "" {
+		for _, login := range Config.Identities {
+			if !contains(logins, login) {
+				logins = append(logins, login)
+			}
+		}
+		if len(logins) == 0 {
+			return nil, errors.New("Nobody is linked in the identities of config.json, try /workload team")
+		}
+	} else if members, ok := lookupNamedList(WorkloadConfig.Teams, team); ok {
+		logins = members
+	} else {
+		var err error
+		logins, err = service.TeamMembers(WorkloadConfig.Owner, team)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Sort(CaseInsensitiveSorter(logins))
+	return logins, nil
+}
+
+// BuildWorkloadItems shows each person's open issues per lane and open pull requests,
+// followed by how many in progress issues nobody is assigned to, naming the first few.
+func BuildWorkloadItems(workloads []githubservice.Workload, unassigned []github.Issue, lanes []githubservice.Lane) []ResultItem {
+	var items []ResultItem
+
+	for _, workload := range workloads {
+		if workload.Err != nil {
+			items = append(items, ResultItem{
+				Title: workload.Login,
+				Text:  "Error: " + workload.Err.Error(),
+				Color: "#ff0000",
+			})
+			continue
+		}
+
+		var fields []ResultField
+		for _, lane := range lanes {
+			if count := len(workload.ByLane[lane.Name]); count > 0 {
+				fields = append(fields, ResultField{Title: lane.Name, Value: strconv.Itoa(count), Short: true})
+			}
+		}
+		if len(workload.PullRequests) > 0 {
+			fields = append(fields, ResultField{Title: "Open pull requests", Value: strconv.Itoa(len(workload.PullRequests)), Short: true})
+		}
+
+		items = append(items, ResultItem{
+			Title:  workload.Login,
+			Text:   strconv.Itoa(workload.Issues()) + " open " + pluralize(workload.Issues(), "issue", "issues") + ", " + strconv.Itoa(len(workload.PullRequests)) + " open " + pluralize(len(workload.PullRequests), "pull request", "pull requests"),
+			Color:  "#439FE0",
+			Fields: fields,
+		})
+	}
+
+	if len(unassigned) > 0 {
+		items = append(items, ResultItem{
+			Title: "In progress but unassigned (" + strconv.Itoa(len(unassigned)) + ")",
+			Text:  issueLines(unassigned),
+			Color: "#ff1010",
+		})
+	}
+
+	if len(items) == 0 {
+		items = append(items, ResultItem{
+			Text:  "Nobody has any open work",
+			Color: "#A0A0A0",
+		})
+	}
+	return items
+}
+
+func (r WorkloadBot) Description() (description string) {
+	// In addition to a Run method, each Robot must implement a Description method which
+	// is just a simple string describing what the Robot does. This is used in the included
+	// /c command which gives users a list of commands and descriptions
+	return "This is a description for WorkloadBot which will be displayed on /c"
+}
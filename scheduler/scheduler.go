@@ -0,0 +1,150 @@
+package scheduler
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/RobotsAndPencils/marvin/store"
+)
+
+const (
+	jobsBucket = "jobs"
+	dateFormat = "2006-01-02"
+
+	// GracePeriod is how late a job may still start after Marvin starts. A restart
+	// within it catches up on the job, while one long after the job's time leaves it
+	// for tomorrow rather than posting the day's reports and messages again. Once
+	// running, a job whose time came while other jobs ran still runs however late.
+	GracePeriod = 10 * time.Minute
+)
+
+// Scheduler runs jobs once a day at a set time of day. The day each job last ran is
+// kept in the store, so a restart doesn't repeat a job, and a job whose time passed
+// a moment before Marvin came back still runs.
+type Scheduler struct {
+	store    *store.Store
+	location *time.Location
+	mutex    sync.Mutex
+	jobs     []job
+	checked  time.Time
+}
+
+type job struct {
+	name   string
+	hour   int
+	minute int
+	run    func(now time.Time)
+}
+
+// New returns a scheduler that reads times of day in location.
+func New(s *store.Store, location *time.Location) *Scheduler {
+	if location == nil {
+		location = time.Local
+	}
+	return &Scheduler{store: s, location: location}
+}
+
+// Daily adds a job that runs every day at the given time, written as 15:04. A job
+// with the same name is replaced, so jobs can be rescheduled while the scheduler runs.
+func (s *Scheduler) Daily(name string, at string, run func(now time.Time)) error {
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return errors.New("Invalid time of day " + at + " for " + name + ", use 15:04")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.jobs = append(s.withoutJob(name), job{name: name, hour: t.Hour(), minute: t.Minute(), run: run})
+	sort.Sort(jobsByName(s.jobs))
+	return nil
+}
+
+// Remove stops running the job with the given name.
+func (s *Scheduler) Remove(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.jobs = s.withoutJob(name)
+}
+
+func (s *Scheduler) withoutJob(name string) []job {
+	var jobs []job
+	for _, j := range s.jobs {
+		if j.name != name {
+			jobs = append(jobs, j)
+		}
+	}
+	return jobs
+}
+
+// Start checks for due jobs right away and then every minute.
+func (s *Scheduler) Start() {
+	go func() {
+		s.RunDue(time.Now())
+		for now := range time.Tick(time.Minute) {
+			s.RunDue(now)
+		}
+	}()
+}
+
+// RunDue runs every job whose time today came since the last check, or within the
+// grace period on the first check, and that has not run today yet.
+// Jobs run one after another so two jobs never compete for the GitHub rate limit.
+func (s *Scheduler) RunDue(now time.Time) {
+	now = now.In(s.location)
+	today := now.Format(dateFormat)
+
+	s.mutex.Lock()
+	jobs := append([]job{}, s.jobs...)
+	checked := s.checked
+	s.checked = now
+	s.mutex.Unlock()
+
+	for _, j := range jobs {
+		due := time.Date(now.Year(), now.Month(), now.Day(), j.hour, j.minute, 0, 0, s.location)
+		if now.Before(due) {
+			continue
+		}
+		if now.Sub(due) > GracePeriod && (checked.IsZero() || !due.After(checked)) {
+			continue
+		}
+
+		var lastRun string
+		_, err := s.store.Get(jobsBucket, j.name, &lastRun)
+		if err != nil {
+			log.Printf("ERROR: Couldn't read when %s last ran: %s", j.name, err)
+			continue
+		}
+		if lastRun == today {
+			continue
+		}
+
+		// Record the run first so a job that panics or hangs isn't started again every minute.
+		err = s.store.Put(jobsBucket, j.name, today)
+		if err != nil {
+			log.Printf("ERROR: Couldn't record the run of %s: %s", j.name, err)
+			continue
+		}
+		log.Printf("Running scheduled job %s", j.name)
+		j.runSafely(now)
+	}
+}
+
+// runSafely runs the job, logging a panic instead of letting it stop Marvin and the
+// jobs after it.
+func (j job) runSafely(now time.Time) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("ERROR: Scheduled job %s panicked: %v", j.name, r)
+		}
+	}()
+	j.run(now)
+}
+
+type jobsByName []job
+
+func (j jobsByName) Len() int           { return len(j) }
+func (j jobsByName) Swap(a, b int)      { j[a], j[b] = j[b], j[a] }
+func (j jobsByName) Less(a, b int) bool { return j[a].name < j[b].name }
@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/RobotsAndPencils/marvin/store"
+	. "github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func Test(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Scheduler", func() {
+		var directory string
+		var s *store.Store
+
+		g.BeforeEach(func() {
+			directory, _ = ioutil.TempDir("", "marvin-scheduler")
+			s, _ = store.Open(filepath.Join(directory, "data.json"))
+		})
+
+		g.AfterEach(func() {
+			os.RemoveAll(directory)
+		})
+
+		g.It("Should run a job once a day after its time", func() {
+			runs := 0
+			scheduler := New(s, time.UTC)
+			scheduler.Daily("snapshot", "06:30", func(now time.Time) { runs++ })
+
+			scheduler.RunDue(time.Date(2016, time.March, 1, 6, 0, 0, 0, time.UTC))
+			Expect(runs).To(Equal(0))
+
+			scheduler.RunDue(time.Date(2016, time.March, 1, 6, 30, 0, 0, time.UTC))
+			scheduler.RunDue(time.Date(2016, time.March, 1, 6, 31, 0, 0, time.UTC))
+			Expect(runs).To(Equal(1))
+
+			scheduler.RunDue(time.Date(2016, time.March, 2, 6, 35, 0, 0, time.UTC))
+			Expect(runs).To(Equal(2))
+		})
+
+		g.It("Should remember runs after a restart", func() {
+			runs := 0
+			due := time.Date(2016, time.March, 1, 6, 30, 0, 0, time.UTC)
+
+			first := New(s, time.UTC)
+			first.Daily("snapshot", "06:30", func(now time.Time) { runs++ })
+			first.RunDue(due)
+
+			second := New(s, time.UTC)
+			second.Daily("snapshot", "06:30", func(now time.Time) { runs++ })
+			second.RunDue(due.Add(2 * time.Minute))
+
+			Expect(runs).To(Equal(1))
+		})
+
+		g.It("Should catch up on a job only shortly after its time", func() {
+			runs := 0
+			scheduler := New(s, time.UTC)
+			scheduler.Daily("snapshot", "06:30", func(now time.Time) { runs++ })
+
+			scheduler.RunDue(time.Date(2016, time.March, 1, 12, 0, 0, 0, time.UTC))
+			Expect(runs).To(Equal(0))
+
+			scheduler.RunDue(time.Date(2016, time.March, 2, 6, 38, 0, 0, time.UTC))
+			Expect(runs).To(Equal(1))
+		})
+
+		g.It("Should run jobs whose time came while other jobs ran", func() {
+			runs := 0
+			scheduler := New(s, time.UTC)
+			scheduler.Daily("snapshot", "06:30", func(now time.Time) { runs++ })
+
+			scheduler.RunDue(time.Date(2016, time.March, 1, 6, 0, 0, 0, time.UTC))
+			scheduler.RunDue(time.Date(2016, time.March, 1, 7, 0, 0, 0, time.UTC))
+
+			Expect(runs).To(Equal(1))
+		})
+
+		g.It("Should keep running jobs after one panics", func() {
+			runs := 0
+			scheduler := New(s, time.UTC)
+			scheduler.Daily("broken", "06:30", func(now time.Time) { panic("broken") })
+			scheduler.Daily("snapshot", "06:30", func(now time.Time) { runs++ })
+
+			scheduler.RunDue(time.Date(2016, time.March, 1, 6, 30, 0, 0, time.UTC))
+
+			Expect(runs).To(Equal(1))
+		})
+
+		g.It("Should replace and remove jobs by name", func() {
+			early, late := 0, 0
+			scheduler := New(s, time.UTC)
+			scheduler.Daily("digest", "06:30", func(now time.Time) { early++ })
+			scheduler.Daily("digest", "09:00", func(now time.Time) { late++ })
+
+			scheduler.RunDue(time.Date(2016, time.March, 1, 7, 0, 0, 0, time.UTC))
+			Expect(early + late).To(Equal(0))
+
+			scheduler.RunDue(time.Date(2016, time.March, 1, 9, 0, 0, 0, time.UTC))
+			Expect(late).To(Equal(1))
+
+			scheduler.Remove("digest")
+			scheduler.RunDue(time.Date(2016, time.March, 2, 9, 0, 0, 0, time.UTC))
+			Expect(early + late).To(Equal(1))
+		})
+
+		g.It("Should reject times it can't read", func() {
+			err := New(s, time.UTC).Daily("snapshot", "half past six", func(now time.Time) {})
+
+			Expect(err).ToNot(BeNil())
+		})
+	})
+}
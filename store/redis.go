@@ -0,0 +1,265 @@
+package store
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Each bucket is a Redis hash under redisPrefix plus its name, with a field per
+// document, and redisBucketsKey is the set of bucket names. Older versions kept the
+// whole store as one document under redisLegacyKey, which is moved over on loading.
+const (
+	redisPrefix     = "marvin:"
+	redisBucketsKey = "marvin:buckets"
+	redisLegacyKey  = "marvin:data"
+)
+
+const redisTimeout = 10 * time.Second
+
+// RedisOptions secure the connection to a rediss:// URL. CAFile is a PEM file of the
+// certificate authorities to trust instead of the system ones, for servers such as
+// Heroku Redis that present a self-signed certificate. SkipVerify turns certificate
+// checks off altogether, so anyone between Marvin and Redis could read or change what
+// it keeps, and has to be asked for explicitly.
+type RedisOptions struct {
+	CAFile     string
+	SkipVerify bool
+}
+
+// OpenRedis loads the store kept in the Redis server at a URL such as
+// redis://:password@host:6379/0, or rediss:// for TLS, as Heroku Redis hands out
+// in REDIS_URL. Unlike a file it survives a Heroku dyno restarting.
+func OpenRedis(rawURL string, options RedisOptions) (*Store, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, errors.New("Unsupported Redis URL scheme " + u.Scheme + ", use redis:// or rediss://")
+	}
+
+	tlsConfig := &tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: options.SkipVerify}
+	if options.CAFile != "" {
+		pem, err := ioutil.ReadFile(options.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("There are no certificates in " + options.CAFile)
+		}
+	}
+	return open(redisBackend{url: u, tlsConfig: tlsConfig})
+}
+
+// redisBackend speaks just enough of the Redis protocol to keep each document in a
+// field of its own, so a change only writes the document that changed. The store is
+// written rarely, so each load or save uses its own connection.
+type redisBackend struct {
+	url       *url.URL
+	tlsConfig *tls.Config
+}
+
+func (r redisBackend) load() (map[string]map[string]json.RawMessage, error) {
+	conn, err := r.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	data := make(map[string]map[string]json.RawMessage)
+	buckets, err := conn.command("SMEMBERS", redisBucketsKey)
+	if err != nil {
+		return nil, err
+	}
+	for _, bucket := range buckets {
+		fields, err := conn.command("HGETALL", redisPrefix+string(bucket))
+		if err != nil {
+			return nil, err
+		}
+		documents := make(map[string]json.RawMessage)
+		for i := 0; i+1 < len(fields); i += 2 {
+			documents[string(fields[i])] = json.RawMessage(fields[i+1])
+		}
+		data[string(bucket)] = documents
+	}
+	if len(buckets) == 0 {
+		return r.migrate(conn)
+	}
+	return data, nil
+}
+
+// migrate moves a store kept as one document into a hash per bucket.
+func (r redisBackend) migrate(conn *redisConn) (map[string]map[string]json.RawMessage, error) {
+	legacy, err := conn.command("GET", redisLegacyKey)
+	if err != nil || legacy[0] == nil {
+		return nil, err
+	}
+	var data map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(legacy[0], &data); err != nil {
+		return nil, err
+	}
+
+	for bucket, documents := range data {
+		for key, raw := range documents {
+			if _, err := conn.command("SADD", redisBucketsKey, bucket); err != nil {
+				return nil, err
+			}
+			if _, err := conn.command("HSET", redisPrefix+bucket, key, string(raw)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	_, err = conn.command("DEL", redisLegacyKey)
+	return data, err
+}
+
+func (r redisBackend) save(data map[string]map[string]json.RawMessage, bucket string, key string) error {
+	conn, err := r.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	raw, ok := data[bucket][key]
+	if !ok {
+		_, err = conn.command("HDEL", redisPrefix+bucket, key)
+		return err
+	}
+	if _, err = conn.command("SADD", redisBucketsKey, bucket); err != nil {
+		return err
+	}
+	_, err = conn.command("HSET", redisPrefix+bucket, key, string(raw))
+	return err
+}
+
+type redisConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// dial connects to the server, logs in and selects the database in the URL.
+func (r redisBackend) dial() (*redisConn, error) {
+	host := r.url.Host
+	if r.url.Port() == "" {
+		host = net.JoinHostPort(r.url.Hostname(), "6379")
+	}
+
+	dialer := &net.Dialer{Timeout: redisTimeout}
+	var conn net.Conn
+	var err error
+	if r.url.Scheme == "rediss" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, r.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", host)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(redisTimeout))
+	c := &redisConn{Conn: conn, reader: bufio.NewReader(conn)}
+
+	if r.url.User != nil {
+		password, _ := r.url.User.Password()
+		auth := []string{"AUTH", password}
+		if username := r.url.User.Username(); username != "" {
+			auth = []string{"AUTH", username, password}
+		}
+		if _, err := c.command(auth...); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if db := strings.TrimPrefix(r.url.Path, "/"); db != "" && db != "0" {
+		if _, err := c.command("SELECT", db); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// command sends a command and reads its reply: the elements of an array, or else a
+// single value. A missing value is nil.
+func (c *redisConn) command(args ...string) ([][]byte, error) {
+	request := "*" + strconv.Itoa(len(args)) + "\r\n"
+	for _, arg := range args {
+		request += "$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n"
+	}
+	if _, err := io.WriteString(c, request); err != nil {
+		return nil, err
+	}
+
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if line[0] != '*' {
+		value, err := c.readValue(line)
+		return [][]byte{value}, err
+	}
+
+	count, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+	var values [][]byte
+	for i := 0; i < count; i++ {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		value, err := c.readValue(line)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func (c *redisConn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", errors.New("Empty reply from Redis")
+	}
+	return line, nil
+}
+
+// readValue reads the value a reply line starts.
+func (c *redisConn) readValue(line string) ([]byte, error) {
+	switch line[0] {
+	case '+', ':':
+		return []byte(line[1:]), nil
+	case '-':
+		return nil, errors.New("Redis: " + line[1:])
+	case '$':
+		length, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if length < 0 {
+			return nil, nil
+		}
+		value := make([]byte, length+2)
+		if _, err := io.ReadFull(c.reader, value); err != nil {
+			return nil, err
+		}
+		return value[:length], nil
+	}
+	return nil, errors.New("Unexpected reply from Redis: " + line)
+}
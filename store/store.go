@@ -0,0 +1,134 @@
+package store
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Store keeps small JSON documents grouped in buckets, in a single file on disk or
+// in Redis. Every change is written straight through. A file is rewritten whole on
+// each change, which suits a development machine, while Redis only writes the
+// document that changed.
+type Store struct {
+	backend backend
+	mutex   sync.Mutex
+	data    map[string]map[string]json.RawMessage
+}
+
+// backend is where the store is kept between restarts. load returns nil when nothing
+// has been kept yet, and save is handed the whole store after the document under
+// bucket and key changed or was deleted.
+type backend interface {
+	load() (map[string]map[string]json.RawMessage, error)
+	save(data map[string]map[string]json.RawMessage, bucket string, key string) error
+}
+
+// Open loads the store kept at path, starting an empty one if the file does not exist yet.
+func Open(path string) (*Store, error) {
+	return open(fileBackend{path: path})
+}
+
+func open(b backend) (*Store, error) {
+	data, err := b.load()
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		data = make(map[string]map[string]json.RawMessage)
+	}
+	return &Store{backend: b, data: data}, nil
+}
+
+// Get decodes the document stored under key into v and says whether there was one.
+func (s *Store) Get(bucket string, key string, v interface{}) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	raw, ok := s.data[bucket][key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+func (s *Store) Put(bucket string, key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.data[bucket] == nil {
+		s.data[bucket] = make(map[string]json.RawMessage)
+	}
+	s.data[bucket][key] = raw
+	return s.backend.save(s.data, bucket, key)
+}
+
+func (s *Store) Delete(bucket string, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.data[bucket][key]; !ok {
+		return nil
+	}
+	delete(s.data[bucket], key)
+	return s.backend.save(s.data, bucket, key)
+}
+
+// Keys lists the keys in a bucket in sorted order.
+func (s *Store) Keys(bucket string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var keys []string
+	for key := range s.data[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type fileBackend struct {
+	path string
+}
+
+func (f fileBackend) load() (map[string]map[string]json.RawMessage, error) {
+	contents, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]map[string]json.RawMessage
+	return data, json.Unmarshal(contents, &data)
+}
+
+// save writes the whole store to a temporary file and moves it into place so a crash
+// halfway through never leaves a truncated file behind.
+func (f fileBackend) save(data map[string]map[string]json.RawMessage, bucket string, key string) error {
+	contents, err := json.MarshalIndent(data, "", "\t")
+	if err != nil {
+		return err
+	}
+	temp, err := ioutil.TempFile(filepath.Dir(f.path), filepath.Base(f.path))
+	if err != nil {
+		return err
+	}
+	_, err = temp.Write(contents)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+	return os.Rename(temp.Name(), f.path)
+}
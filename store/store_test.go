@@ -0,0 +1,253 @@
+package store
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+// fakeRedis answers the few commands the store sends, keeping hashes and sets in
+// memory and counting the fields each HSET writes.
+func fakeRedis() (address string, password *string, written func() int, stop func()) {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	values := make(map[string]string)
+	hashes := make(map[string]map[string]string)
+	sets := make(map[string]map[string]bool)
+	fields := 0
+	var mutex sync.Mutex
+	expected := ""
+	password = &expected
+
+	bulk := func(value string) string {
+		return "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+	}
+	array := func(values []string) string {
+		reply := "*" + strconv.Itoa(len(values)) + "\r\n"
+		for _, value := range values {
+			reply += bulk(value)
+		}
+		return reply
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				authenticated := *password == ""
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+					var args []string
+					for i := 0; i < count; i++ {
+						header, _ := reader.ReadString('\n')
+						length, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+						value := make([]byte, length+2)
+						io.ReadFull(reader, value)
+						args = append(args, string(value[:length]))
+					}
+
+					mutex.Lock()
+					switch {
+					case args[0] == "AUTH":
+						authenticated = args[len(args)-1] == *password
+						if authenticated {
+							io.WriteString(conn, "+OK\r\n")
+						} else {
+							io.WriteString(conn, "-WRONGPASS invalid password\r\n")
+						}
+					case !authenticated:
+						io.WriteString(conn, "-NOAUTH Authentication required\r\n")
+					case args[0] == "SET":
+						values[args[1]] = args[2]
+						io.WriteString(conn, "+OK\r\n")
+					case args[0] == "GET":
+						if value, ok := values[args[1]]; ok {
+							io.WriteString(conn, bulk(value))
+						} else {
+							io.WriteString(conn, "$-1\r\n")
+						}
+					case args[0] == "DEL":
+						delete(values, args[1])
+						io.WriteString(conn, ":1\r\n")
+					case args[0] == "SADD":
+						if sets[args[1]] == nil {
+							sets[args[1]] = make(map[string]bool)
+						}
+						sets[args[1]][args[2]] = true
+						io.WriteString(conn, ":1\r\n")
+					case args[0] == "SMEMBERS":
+						var members []string
+						for member := range sets[args[1]] {
+							members = append(members, member)
+						}
+						io.WriteString(conn, array(members))
+					case args[0] == "HSET":
+						if hashes[args[1]] == nil {
+							hashes[args[1]] = make(map[string]string)
+						}
+						hashes[args[1]][args[2]] = args[3]
+						fields += (len(args) - 2) / 2
+						io.WriteString(conn, ":1\r\n")
+					case args[0] == "HGETALL":
+						var values []string
+						for field, value := range hashes[args[1]] {
+							values = append(values, field, value)
+						}
+						io.WriteString(conn, array(values))
+					case args[0] == "HDEL":
+						delete(hashes[args[1]], args[2])
+						io.WriteString(conn, ":1\r\n")
+					default:
+						io.WriteString(conn, "+OK\r\n")
+					}
+					mutex.Unlock()
+				}
+			}(conn)
+		}
+	}()
+	written = func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return fields
+	}
+	return listener.Addr().String(), password, written, func() { listener.Close() }
+}
+
+func Test(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Store", func() {
+		var directory string
+
+		g.BeforeEach(func() {
+			directory, _ = ioutil.TempDir("", "marvin-store")
+		})
+
+		g.AfterEach(func() {
+			os.RemoveAll(directory)
+		})
+
+		g.It("Should keep documents after it is opened again", func() {
+			path := filepath.Join(directory, "data.json")
+			s, err := Open(path)
+			Expect(err).To(BeNil())
+
+			err = s.Put("subscriptions", "C123", []string{"marvin", "pencilcase"})
+			Expect(err).To(BeNil())
+
+			reopened, err := Open(path)
+			Expect(err).To(BeNil())
+
+			var repos []string
+			found, err := reopened.Get("subscriptions", "C123", &repos)
+			Expect(found).To(BeTrue())
+			Expect(err).To(BeNil())
+			Expect(repos).To(Equal([]string{"marvin", "pencilcase"}))
+		})
+
+		g.It("Should keep documents in Redis", func() {
+			address, password, _, stop := fakeRedis()
+			defer stop()
+			*password = "secret"
+
+			s, err := OpenRedis("redis://:secret@"+address+"/0", RedisOptions{})
+			Expect(err).To(BeNil())
+			Expect(s.Keys("subscriptions")).To(BeEmpty())
+
+			err = s.Put("subscriptions", "C123", []string{"marvin"})
+			Expect(err).To(BeNil())
+			err = s.Put("subscriptions", "C456", []string{"gambit"})
+			Expect(err).To(BeNil())
+			err = s.Delete("subscriptions", "C456")
+			Expect(err).To(BeNil())
+
+			reopened, err := OpenRedis("redis://:secret@"+address+"/0", RedisOptions{})
+			Expect(err).To(BeNil())
+
+			var repos []string
+			found, _ := reopened.Get("subscriptions", "C123", &repos)
+			Expect(found).To(BeTrue())
+			Expect(repos).To(Equal([]string{"marvin"}))
+			Expect(reopened.Keys("subscriptions")).To(Equal([]string{"C123"}))
+
+			_, err = OpenRedis("redis://:wrong@"+address+"/0", RedisOptions{})
+			Expect(err).ToNot(BeNil())
+		})
+
+		g.It("Should only write the changed document to Redis", func() {
+			address, _, written, stop := fakeRedis()
+			defer stop()
+
+			s, _ := OpenRedis("redis://"+address+"/0", RedisOptions{})
+			for i := 0; i < 5; i++ {
+				s.Put("flow", strconv.Itoa(i), i)
+			}
+
+			Expect(written()).To(Equal(5))
+		})
+
+		g.It("Should move a store kept as one document into a hash per bucket", func() {
+			address, _, written, stop := fakeRedis()
+			defer stop()
+
+			conn, _ := redisBackend{url: &url.URL{Scheme: "redis", Host: address}}.dial()
+			conn.command("SET", redisLegacyKey, `{"subscriptions": {"C123": ["marvin"]}}`)
+			conn.Close()
+
+			s, err := OpenRedis("redis://"+address+"/0", RedisOptions{})
+			Expect(err).To(BeNil())
+			Expect(s.Keys("subscriptions")).To(Equal([]string{"C123"}))
+			Expect(written()).To(Equal(1))
+
+			reopened, _ := OpenRedis("redis://"+address+"/0", RedisOptions{})
+			var repos []string
+			found, _ := reopened.Get("subscriptions", "C123", &repos)
+			Expect(found).To(BeTrue())
+			Expect(repos).To(Equal([]string{"marvin"}))
+		})
+
+		g.It("Should refuse a certificate authority file without certificates", func() {
+			caFile := filepath.Join(directory, "ca.pem")
+			ioutil.WriteFile(caFile, []byte("not a certificate"), 0644)
+
+			_, err := OpenRedis("rediss://:secret@localhost:6380/0", RedisOptions{CAFile: caFile})
+			Expect(err).ToNot(BeNil())
+			Expect(err.Error()).To(ContainSubstring("no certificates"))
+		})
+
+		g.It("Should forget deleted documents", func() {
+			s, _ := Open(filepath.Join(directory, "data.json"))
+			s.Put("subscriptions", "C123", "marvin")
+			s.Put("subscriptions", "C456", "gambit")
+
+			s.Delete("subscriptions", "C123")
+
+			var repo string
+			found, _ := s.Get("subscriptions", "C123", &repo)
+			Expect(found).To(BeFalse())
+			Expect(s.Keys("subscriptions")).To(Equal([]string{"C456"}))
+		})
+	})
+}
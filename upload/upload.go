@@ -0,0 +1,161 @@
+// Package upload posts files such as chart images to Slack channels.
+package upload
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// File is a file to post to a channel, with an optional message shown above it.
+type File struct {
+	Channel  string
+	Filename string
+	Title    string
+	Comment  string
+	Content  []byte
+}
+
+// Uploader posts files to channels.
+type Uploader interface {
+	Upload(file File) error
+}
+
+// Slack uploads files with Slack's external upload API: it asks for an upload URL,
+// sends the content there and then shares the finished file in the channel. The
+// token is a bot token with the files:write scope.
+type Slack struct {
+	Token   string
+	BaseURL string
+	Client  *http.Client
+}
+
+func NewSlack(token string) *Slack {
+	return &Slack{Token: token, BaseURL: "https://slack.com/api/", Client: http.DefaultClient}
+}
+
+type slackResponse struct {
+	OK        bool   `json:"ok"`
+	Error     string `json:"error"`
+	UploadURL string `json:"upload_url"`
+	FileID    string `json:"file_id"`
+}
+
+func (s *Slack) Upload(file File) error {
+	query := url.Values{}
+	query.Set("filename", file.Filename)
+	query.Set("length", strconv.Itoa(len(file.Content)))
+	req, err := http.NewRequest("GET", s.BaseURL+"files.getUploadURLExternal?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	var ticket slackResponse
+	err = s.call(req, &ticket)
+	if err != nil {
+		return err
+	}
+
+	req, err = http.NewRequest("POST", ticket.UploadURL, bytes.NewReader(file.Content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("Slack refused the upload of " + file.Filename + ": " + resp.Status)
+	}
+
+	title := file.Title
+	if title == "" {
+		title = file.Filename
+	}
+	complete := map[string]interface{}{
+		"files":      []map[string]string{{"id": ticket.FileID, "title": title}},
+		"channel_id": file.Channel,
+	}
+	if file.Comment != "" {
+		complete["initial_comment"] = file.Comment
+	}
+	body, err := json.Marshal(complete)
+	if err != nil {
+		return err
+	}
+	req, err = http.NewRequest("POST", s.BaseURL+"files.completeUploadExternal", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	return s.call(req, &slackResponse{})
+}
+
+// call sends an authenticated request to the Slack API, which reports failures in
+// the body rather than with the status code.
+func (s *Slack) call(req *http.Request, v *slackResponse) error {
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	contents, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(contents, v)
+	if err != nil {
+		return errors.New("Slack sent an unexpected response: " + resp.Status)
+	}
+	if !v.OK {
+		return errors.New("Slack error: " + v.Error)
+	}
+	return nil
+}
+
+// Local keeps only the most recent uploads in memory, as it also runs in production
+// when there is no bot token.
+const rememberedUploads = 10
+
+// Local stands in for Slack when there is no bot token and in tests. It saves files
+// in Directory, when there is one, and remembers the last few uploads.
+type Local struct {
+	Directory string
+	mutex     sync.Mutex
+	uploaded  []File
+}
+
+func (l *Local) Upload(file File) error {
+	l.mutex.Lock()
+	l.uploaded = append(l.uploaded, file)
+	if len(l.uploaded) > rememberedUploads {
+		l.uploaded = append([]File{}, l.uploaded[len(l.uploaded)-rememberedUploads:]...)
+	}
+	l.mutex.Unlock()
+
+	if l.Directory == "" {
+		return nil
+	}
+	err := os.MkdirAll(l.Directory, 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(l.Directory, filepath.Base(file.Filename)), file.Content, 0644)
+}
+
+// Uploaded lists the last few files uploaded, oldest first.
+func (l *Local) Uploaded() []File {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return append([]File{}, l.uploaded...)
+}
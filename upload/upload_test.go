@@ -0,0 +1,100 @@
+package upload
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	. "github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+)
+
+func Test(t *testing.T) {
+	g := Goblin(t)
+
+	// special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	chart := File{Channel: "C123", Filename: "flow.png", Title: "Flow of marvin", Content: []byte("PNG")}
+
+	g.Describe("Slack", func() {
+		g.It("Should upload the file and share it in the channel", func() {
+			var received []byte
+			var completed map[string]interface{}
+
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/files.getUploadURLExternal":
+					Expect(r.Header.Get("Authorization")).To(Equal("Bearer xoxb-token"))
+					Expect(r.URL.Query().Get("filename")).To(Equal("flow.png"))
+					Expect(r.URL.Query().Get("length")).To(Equal("3"))
+					w.Write([]byte(`{"ok": true, "upload_url": "` + server.URL + `/upload/F1", "file_id": "F1"}`))
+				case "/upload/F1":
+					received, _ = ioutil.ReadAll(r.Body)
+				case "/api/files.completeUploadExternal":
+					json.NewDecoder(r.Body).Decode(&completed)
+					w.Write([]byte(`{"ok": true}`))
+				}
+			}))
+			defer server.Close()
+
+			slack := NewSlack("xoxb-token")
+			slack.BaseURL = server.URL + "/api/"
+			err := slack.Upload(chart)
+
+			Expect(err).To(BeNil())
+			Expect(string(received)).To(Equal("PNG"))
+			Expect(completed["channel_id"]).To(Equal("C123"))
+			Expect(completed["files"]).To(Equal([]interface{}{map[string]interface{}{"id": "F1", "title": "Flow of marvin"}}))
+		})
+
+		g.It("Should report errors from Slack", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"ok": false, "error": "not_in_channel"}`))
+			}))
+			defer server.Close()
+
+			slack := NewSlack("xoxb-token")
+			slack.BaseURL = server.URL + "/"
+			err := slack.Upload(chart)
+
+			Expect(err).ToNot(BeNil())
+			Expect(err.Error()).To(ContainSubstring("not_in_channel"))
+		})
+	})
+
+	g.Describe("Local", func() {
+		g.It("Should save and remember uploaded files", func() {
+			directory, _ := ioutil.TempDir("", "marvin-upload")
+			defer os.RemoveAll(directory)
+
+			local := &Local{Directory: directory}
+			err := local.Upload(chart)
+
+			Expect(err).To(BeNil())
+			Expect(local.Uploaded()).To(HaveLen(1))
+			saved, _ := ioutil.ReadFile(filepath.Join(directory, "flow.png"))
+			Expect(string(saved)).To(Equal("PNG"))
+		})
+
+		g.It("Should only remember the last few uploads", func() {
+			local := &Local{}
+			for i := 0; i < rememberedUploads+5; i++ {
+				file := chart
+				file.Title = strconv.Itoa(i)
+				local.Upload(file)
+			}
+
+			uploaded := local.Uploaded()
+			Expect(uploaded).To(HaveLen(rememberedUploads))
+			Expect(uploaded[0].Title).To(Equal("5"))
+			Expect(uploaded[rememberedUploads-1].Title).To(Equal(strconv.Itoa(rememberedUploads + 4)))
+		})
+	})
+}